@@ -1,8 +1,12 @@
 package account
 
 import (
+	"context"
+	"net/url"
+
 	"github.com/google/uuid"
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type (
@@ -95,6 +99,28 @@ type (
 		// false otherwise. (OPTIONAL)
 		Switched bool
 	}
+	// UpdateRequest groups attributes that can be changed in an existing Account resource. Only non-nil attributes
+	// are sent to account-api, the others are left unchanged. Non-nil attributes are sent even when empty, so an
+	// attribute is cleared with an empty value, e.g. Name: []string{} or SecondaryIdentification: new(string).
+	//
+	// See: https://api-docs.form3.tech/api.html#organisation-accounts-patch
+	UpdateRequest struct {
+		// Name of the account holder, up to four lines possible. (OPTIONAL)
+		Name []string
+		// AlternativeNames refers to the primary account names, only used for UK Confirmation of Payee. (OPTIONAL)
+		AlternativeNames []string
+		// SecondaryIdentification is the additional information to identify the account and account holder, only used
+		// for Confirmation of Payee (CoP). (OPTIONAL)
+		SecondaryIdentification *string
+		// Switched is a flag to indicate if the account has been switched away from this organisation, only used for
+		// Confirmation of Payee (CoP). (OPTIONAL)
+		Switched *bool
+		// MatchingOptOut is a flag to indicate if the account has opted out of account matching, only used for
+		// Confirmation of Payee (CoP). (OPTIONAL)
+		MatchingOptOut *bool
+		// Status of the account. (OPTIONAL)
+		Status *Status
+	}
 	// DeleteRequest is an interface that provides the contract to delete an account.
 	//
 	// Is not necessary implement this interface to delete an account, one could use BuildDeleteRequest function instead
@@ -126,15 +152,44 @@ type (
 	}
 	// Service provides the main API to interact with account-api.
 	//
-	// It should not be instantiate directly. Use NewService(repo repository) *Service instead.
+	// It should not be instantiate directly. Use NewService(repo Repository) *Service instead.
 	Service struct {
 		inputMapper
 		outputMapper
 		creator
 		retriever
 		eraser
+		lister
+		updater
+		healthChecker
 
-		errCtx string
+		errCtx   string
+		tracer   trace.Tracer
+		validate bool
+	}
+	// Repository provides the low level calls to account-api used by Service. NewHTTPRepository calls a running
+	// account-api and NewInMemoryRepository keeps accounts in memory, e.g. for unit tests and local development.
+	//
+	// It can be implemented outside this package too, e.g. by a fake, a caching layer or another transport. Errors
+	// should match the ones of account-api: an error matching ErrNotFound when the account does not exist, ErrConflict
+	// when creating an account with an existing ID and ErrVersionConflict when the version given is not the current
+	// one. repositorytest.RunConformance, in accounttest/repositorytest, checks an implementation against them.
+	Repository interface {
+		// Create creates the account d, in version 0, and returns it as stored.
+		Create(ctx context.Context, d AccountData) (*AccountData, error)
+		// Fetch returns the account with id.
+		Fetch(ctx context.Context, id string) (*AccountData, error)
+		// Delete deletes the account with id, which must be in version.
+		Delete(ctx context.Context, id string, version int64) error
+		// Update changes the non-nil attributes of the account with d.ID, which must be in d.Version, increments its
+		// version and returns it as stored.
+		Update(ctx context.Context, d AccountData) (*AccountData, error)
+		// List returns the page of accounts matching query, holding the page[number], page[size] and filter[...]
+		// parameters of account-api.
+		List(ctx context.Context, query url.Values) (*AccountList, error)
+		// Health checks the health of the backend. It always returns a HealthStatus, along with an error when it is
+		// not up.
+		Health(ctx context.Context) (*HealthStatus, error)
 	}
 	basicDeleteRequest struct {
 		id string
@@ -142,60 +197,108 @@ type (
 )
 
 type (
-	mapper     struct{}
-	repository interface {
-		creator
-		retriever
-		eraser
-	}
+	mapper  struct{}
 	creator interface {
-		create(data) (*data, error)
+		Create(ctx context.Context, d AccountData) (*AccountData, error)
 	}
 	retriever interface {
-		fetch(id string) (*data, error)
+		Fetch(ctx context.Context, id string) (*AccountData, error)
 	}
 	eraser interface {
-		delete(id string, version int64) error
+		Delete(ctx context.Context, id string, version int64) error
+	}
+	updater interface {
+		Update(ctx context.Context, d AccountData) (*AccountData, error)
+	}
+	lister interface {
+		List(ctx context.Context, query url.Values) (*AccountList, error)
 	}
 	inputMapper interface {
-		toAcc(CreateRequest) *data
+		toAcc(CreateRequest) *AccountData
+		toQuery(ListOptions) url.Values
+		toPatch(id string, version int64, ur UpdateRequest) *AccountData
 	}
 	outputMapper interface {
-		ofAcc(data) (*Entity, error)
+		ofAcc(AccountData) (*Entity, error)
+		ofPage(AccountList) (*Page, error)
+	}
+	serviceOption interface {
+		applyService(*serviceOptions)
+	}
+	serviceOptions struct {
+		tracerProvider trace.TracerProvider
+		skipValidation bool
 	}
 )
 
 // NewService instantiates a Service. It is the only way to instantiate Service.
 //
-// It receives a repository as argument. The argument provides low level RPC to interact with account-api.
-func NewService(repo repository) *Service {
+// It receives a Repository as argument. The argument provides low level RPC to interact with account-api, and
+// optionally serviceOption(s), e.g. WithTracerProvider or WithValidation.
+func NewService(repo Repository, opts ...serviceOption) *Service {
+	var options serviceOptions
+	for _, o := range opts {
+		o.applyService(&options)
+	}
+
+	var tracer trace.Tracer
+	if options.tracerProvider != nil {
+		tracer = options.tracerProvider.Tracer(_instrumentationName)
+	}
+
 	mapper := mapper{}
 	return &Service{
-		errCtx:       "service",
-		creator:      repo,
-		retriever:    repo,
-		eraser:       repo,
-		inputMapper:  mapper,
-		outputMapper: mapper,
+		tracer:        tracer,
+		validate:      !options.skipValidation,
+		errCtx:        "service",
+		creator:       repo,
+		retriever:     repo,
+		eraser:        repo,
+		lister:        repo,
+		updater:       repo,
+		healthChecker: repo,
+		inputMapper:   mapper,
+		outputMapper:  mapper,
 	}
 }
 
 // Create registers an existing bank account with account-api or create a new one. The Country attribute must be
 // specified as a minimum. Depending on the country, other attributes such as BankID and Bic are mandatory.
 //
-// Returns error when CreateRequest -> data, repo.create(), data -> Entity fails.
+// CreateRequest is checked by CreateRequest.Validate first, unless disabled with WithValidation(false), and an invalid
+// one is refused with an error matching ErrInvalidRequest without calling account-api.
+//
+// Returns error when CreateRequest.Validate(), CreateRequest -> AccountData, repo.Create(), AccountData -> Entity fails.
 func (s Service) Create(cr CreateRequest) (*Entity, error) {
+	return s.CreateContext(context.Background(), cr)
+}
+
+// CreateContext is like Create but bounds the call to ctx. If ctx is cancelled or its deadline expires before the
+// account-api answers, the returned error wraps context.Canceled or context.DeadlineExceeded.
+func (s Service) CreateContext(ctx context.Context, cr CreateRequest) (acc *Entity, err error) {
+	ctx, span := s.startSpan(ctx, OperationCreate,
+		_attrAccountID.String(cr.ID),
+		_attrOrganisationID.String(cr.OrganisationID),
+		_attrCountry.String(cr.Country),
+	)
+	defer func() { span.end(acc, err) }()
+
 	wrapErr := func(err error, msg string) error {
 		return errors.Wrapf(err, "%s create_%s: organisationID: %s, country: %s", s.errCtx, msg, cr.OrganisationID, cr.Country)
 	}
+	if s.validate {
+		if err := cr.Validate(); err != nil {
+			return nil, wrapErr(err, "validate")
+		}
+	}
 	data := s.toAcc(cr)
 
-	ret, err := s.create(*data)
+	ret, err := s.creator.Create(ctx, *data)
 	if err != nil {
 		return nil, wrapErr(err, "repo_create")
 	}
 
-	acc, err := s.ofAcc(*ret)
+	acc, err = s.ofAcc(*ret)
 	if err != nil {
 		return nil, wrapErr(err, "ofAcc")
 	}
@@ -205,18 +308,32 @@ func (s Service) Create(cr CreateRequest) (*Entity, error) {
 
 // Fetch gets a single account using the account ID.
 //
+// Returns an error wrapping ErrNotFound when there is no account with the given ID.
+//
 // See: https://api-docs.form3.tech/api.html#organisation-accounts-fetch
 func (s Service) Fetch(id string) (*Entity, error) {
+	return s.FetchContext(context.Background(), id)
+}
+
+// FetchContext is like Fetch but bounds the call to ctx. If ctx is cancelled or its deadline expires before the
+// account-api answers, the returned error wraps context.Canceled or context.DeadlineExceeded.
+func (s Service) FetchContext(ctx context.Context, id string) (acc *Entity, err error) {
+	ctx, span := s.startSpan(ctx, OperationFetch, _attrAccountID.String(id))
+	defer func() { span.end(acc, err) }()
+
 	wrapErr := func(err error, msg string) error {
 		return errors.Wrapf(err, "%s fetch_%s: id: %s", s.errCtx, msg, id)
 	}
 
-	ret, err := s.fetch(id)
+	ret, err := s.retriever.Fetch(ctx, id)
 	if err != nil {
 		return nil, wrapErr(err, "repo_fetch")
 	}
+	if ret == nil {
+		return nil, wrapErr(ErrNotFound, "repo_fetch")
+	}
 
-	acc, err := s.ofAcc(*ret)
+	acc, err = s.ofAcc(*ret)
 	if err != nil {
 		return nil, wrapErr(err, "ofAcc")
 	}
@@ -229,15 +346,61 @@ func (s Service) Fetch(id string) (*Entity, error) {
 // It accepts a DeleteRequest as argument. It uses an interface because it is possible to pass an Entity as argument
 // since it implements DeleteRequest interface. Otherwise one should use BuildDeleteRequest function.
 //
+// Returns an error matching ErrNotFound when the account does not exist and an error matching ErrVersionConflict when
+// the account is not in the version given by DeleteRequest anymore.
+//
 // See: https://api-docs.form3.tech/api.html#organisation-accounts-delete
 func (s Service) Delete(dr DeleteRequest) error {
-	if err := s.delete(dr.ID(), dr.Version()); err != nil {
+	return s.DeleteContext(context.Background(), dr)
+}
+
+// DeleteContext is like Delete but bounds the call to ctx. If ctx is cancelled or its deadline expires before the
+// account-api answers, the returned error wraps context.Canceled or context.DeadlineExceeded.
+func (s Service) DeleteContext(ctx context.Context, dr DeleteRequest) (err error) {
+	ctx, span := s.startSpan(ctx, OperationDelete, _attrAccountID.String(dr.ID()))
+	defer func() { span.end(nil, err) }()
+
+	if err := s.eraser.Delete(ctx, dr.ID(), dr.Version()); err != nil {
 		return errors.Wrapf(err, "%s delete: id: %s", s.errCtx, dr.ID())
 	}
 
 	return nil
 }
 
+// Update changes the attributes of an account given by UpdateRequest. The version must be the current version of the
+// account, e.g. Entity.Version(), otherwise an error matching ErrVersionConflict is returned.
+//
+// Returns error when UpdateRequest -> AccountData, repo.Update(), AccountData -> Entity fails.
+//
+// See: https://api-docs.form3.tech/api.html#organisation-accounts-patch
+func (s Service) Update(id string, version int64, ur UpdateRequest) (*Entity, error) {
+	return s.UpdateContext(context.Background(), id, version, ur)
+}
+
+// UpdateContext is like Update but bounds the call to ctx. If ctx is cancelled or its deadline expires before the
+// account-api answers, the returned error wraps context.Canceled or context.DeadlineExceeded.
+func (s Service) UpdateContext(ctx context.Context, id string, version int64, ur UpdateRequest) (acc *Entity, err error) {
+	ctx, span := s.startSpan(ctx, OperationUpdate, _attrAccountID.String(id))
+	defer func() { span.end(acc, err) }()
+
+	wrapErr := func(err error, msg string) error {
+		return errors.Wrapf(err, "%s update_%s: id: %s, version: %d", s.errCtx, msg, id, version)
+	}
+	data := s.toPatch(id, version, ur)
+
+	ret, err := s.updater.Update(ctx, *data)
+	if err != nil {
+		return nil, wrapErr(err, "repo_update")
+	}
+
+	acc, err = s.ofAcc(*ret)
+	if err != nil {
+		return nil, wrapErr(err, "ofAcc")
+	}
+
+	return acc, nil
+}
+
 // UUID returns the ID as uuid.UUID of the Entity account.
 func (a Entity) UUID() uuid.UUID {
 	return a.id
@@ -354,10 +517,15 @@ func (b basicDeleteRequest) Version() int64 {
 	return int64(0)
 }
 
-func (r mapper) toAcc(cr CreateRequest) *data {
+func (r mapper) toAcc(cr CreateRequest) *AccountData {
 	defaultVersion := int64(0)
-	return &data{
-		Attributes: &attributes{
+	var secondaryIdentification *string
+	if cr.SecondaryIdentification != "" {
+		secondaryIdentification = &cr.SecondaryIdentification
+	}
+
+	return &AccountData{
+		Attributes: &AccountAttributes{
 			Classification:          &cr.Classification,
 			MatchingOptOut:          &cr.MatchingOptOut,
 			Number:                  cr.Number,
@@ -370,7 +538,7 @@ func (r mapper) toAcc(cr CreateRequest) *data {
 			Iban:                    cr.Iban,
 			JointAccount:            &cr.JointAccount,
 			Name:                    cr.Name,
-			SecondaryIdentification: cr.SecondaryIdentification,
+			SecondaryIdentification: secondaryIdentification,
 			Switched:                &cr.Switched,
 		},
 		OrganisationID: cr.OrganisationID,
@@ -380,7 +548,28 @@ func (r mapper) toAcc(cr CreateRequest) *data {
 	}
 }
 
-func (r mapper) ofAcc(d data) (*Entity, error) {
+func (r mapper) toPatch(id string, version int64, ur UpdateRequest) *AccountData {
+	att := &AccountAttributes{
+		Name:                    ur.Name,
+		AlternativeNames:        ur.AlternativeNames,
+		SecondaryIdentification: ur.SecondaryIdentification,
+		MatchingOptOut:          ur.MatchingOptOut,
+		Switched:                ur.Switched,
+	}
+	if ur.Status != nil {
+		status := string(*ur.Status)
+		att.Status = &status
+	}
+
+	return &AccountData{
+		Attributes: att,
+		Type:       "accounts",
+		Version:    &version,
+		ID:         id,
+	}
+}
+
+func (r mapper) ofAcc(d AccountData) (*Entity, error) {
 	id, err := uuid.Parse(d.ID)
 	if err != nil {
 		return nil, errors.Wrapf(err, "ID parse: %s", d.ID)
@@ -426,6 +615,11 @@ func (r mapper) ofAcc(d data) (*Entity, error) {
 		status = Status(*att.Status)
 	}
 
+	var secondaryIdentification string
+	if att.SecondaryIdentification != nil {
+		secondaryIdentification = *att.SecondaryIdentification
+	}
+
 	var switched bool
 	if att.Switched != nil {
 		switched = *att.Switched
@@ -447,7 +641,7 @@ func (r mapper) ofAcc(d data) (*Entity, error) {
 		iban:                    att.Iban,
 		jointAccount:            jointAccount,
 		name:                    att.Name,
-		secondaryIdentification: att.SecondaryIdentification,
+		secondaryIdentification: secondaryIdentification,
 		status:                  status,
 		switched:                switched,
 	}, nil
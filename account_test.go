@@ -1,8 +1,11 @@
 package account
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"net/url"
 	"reflect"
 	"testing"
 
@@ -13,36 +16,38 @@ type (
 	mockErr             struct{}
 	mockInputMapper     struct{}
 	mockOutputMapperErr struct{}
+	mockNotFound        struct{}
+	mockNilData         struct{}
 	mockOk              struct {
 		assertArg bool
-		expData   data
+		expData   AccountData
 	}
 )
 
 const (
-	_idStub                      = "ad27e265-9605-4b4b-a0e5-3003ea9cc4dc"
-	_organisationIDStub          = "eb0bd6f5-c3f5-44b2-b677-acd23cdde73c"
-	_numberStub                  = "666"
-	_bankIDStub                  = "400300"
-	_bankIDCodeStub              = "GBDSC"
-	_baseCurrencyStub            = "GBP"
-	_bicStub                     = "NWBKGB22"
-	_ibanStub                    = "GB33BUKB20201555555555"
-	_secondaryIdentificationStub = "20530441"
+	_idStub             = "ad27e265-9605-4b4b-a0e5-3003ea9cc4dc"
+	_organisationIDStub = "eb0bd6f5-c3f5-44b2-b677-acd23cdde73c"
+	_numberStub         = "666"
+	_bankIDStub         = "400300"
+	_bankIDCodeStub     = "GBDSC"
+	_baseCurrencyStub   = "GBP"
+	_bicStub            = "NWBKGB22"
+	_ibanStub           = "GB33BUKB20201555555555"
 )
 
 var (
-	_versionStub             = int64(0)
-	_uuidStub, _             = uuid.Parse(_idStub)
-	_organisationUUIDStub, _ = uuid.Parse(_organisationIDStub)
-	_alternativeNamesStub    = []string{"Adanedhel"}
-	_nameStub                = []string{"TURIN TURAMBAR"}
-	_classificationStub      = "Personal"
-	_matchingOptOutStub      = true
-	_countryStub             = "GB"
-	_jointAccountStub        = true
-	_statusStub              = "confirmed"
-	_switchedStub            = true
+	_versionStub                 = int64(0)
+	_uuidStub, _                 = uuid.Parse(_idStub)
+	_organisationUUIDStub, _     = uuid.Parse(_organisationIDStub)
+	_alternativeNamesStub        = []string{"Adanedhel"}
+	_nameStub                    = []string{"TURIN TURAMBAR"}
+	_classificationStub          = "Personal"
+	_matchingOptOutStub          = true
+	_countryStub                 = "GB"
+	_jointAccountStub            = true
+	_statusStub                  = "confirmed"
+	_switchedStub                = true
+	_secondaryIdentificationStub = "20530441"
 )
 
 var (
@@ -52,12 +57,15 @@ var (
 		creator:     mockErr{},
 		retriever:   mockErr{},
 		eraser:      mockErr{},
+		lister:      mockErr{},
+		updater:     mockErr{},
 	}
 	_serviceWithOutputMapperError = Service{
 		errCtx:       "service",
 		inputMapper:  mockInputMapper{},
 		creator:      mockOk{expData: _fullyFilledData},
 		retriever:    mockOk{expData: _fullyFilledData},
+		updater:      mockOk{expData: _fullyFilledData},
 		outputMapper: mockOutputMapperErr{},
 	}
 	_serviceWithMockedRepositoryFullyFilled = Service{
@@ -76,14 +84,14 @@ var (
 )
 
 var (
-	_dataWithIDErr = data{
+	_dataWithIDErr = AccountData{
 		ID: "3rr0r",
 	}
-	_dataWithOrganizationIDErr = data{
+	_dataWithOrganizationIDErr = AccountData{
 		ID:             _idStub,
 		OrganisationID: "0RG4N1Z4T10N_3rr0r",
 	}
-	_dataWithAttErr = data{
+	_dataWithAttErr = AccountData{
 		Attributes:     nil,
 		ID:             _idStub,
 		OrganisationID: _organisationIDStub,
@@ -129,8 +137,8 @@ var (
 		status:                  Status(_statusStub),
 		switched:                _switchedStub,
 	}
-	_fullyFilledData = data{
-		Attributes: &attributes{
+	_fullyFilledData = AccountData{
+		Attributes: &AccountAttributes{
 			Classification:          &_classificationStub,
 			MatchingOptOut:          &_matchingOptOutStub,
 			Number:                  _numberStub,
@@ -143,7 +151,7 @@ var (
 			Iban:                    _ibanStub,
 			JointAccount:            &_jointAccountStub,
 			Name:                    _nameStub,
-			SecondaryIdentification: _secondaryIdentificationStub,
+			SecondaryIdentification: &_secondaryIdentificationStub,
 			Status:                  &_statusStub,
 			Switched:                &_switchedStub,
 		},
@@ -182,8 +190,8 @@ var (
 	_basicMatchingOptOutStub = false
 	_basicJointAccountStub   = false
 	_basicSwitchedStub       = false
-	_basicFilledData         = data{
-		Attributes: &attributes{
+	_basicFilledData         = AccountData{
+		Attributes: &AccountAttributes{
 			Number:         _numberStub,
 			BankID:         _bankIDStub,
 			BankIDCode:     _bankIDCodeStub,
@@ -224,7 +232,7 @@ func TestAccountFetchIntegration(t *testing.T) {
 
 	svc := NewService(NewHTTPRepository(WithAddr(*_itAddress)))
 
-	got, err := svc.Fetch(_idStub)
+	got, err := svc.Fetch(_fakeStubID)
 	if err != nil {
 		t.Fatal()
 	}
@@ -312,6 +320,104 @@ func TestFetch_Error(t *testing.T) {
 	}
 }
 
+func TestFetch_NotFound(t *testing.T) {
+	cases := []struct {
+		name string
+		in   retriever
+		want string
+	}{
+		{"repo error", mockNotFound{}, "service fetch_repo_fetch: id: id: id: id: account not found"},
+		{"repo nil data", mockNilData{}, "service fetch_repo_fetch: id: id: account not found"},
+	}
+
+	for _, tt := range cases {
+		svc := Service{errCtx: "service", retriever: tt.in, outputMapper: mapper{}}
+		got, err := svc.Fetch("id")
+		if got != nil || !errors.Is(err, ErrNotFound) || err.Error() != tt.want {
+			t.Errorf("Fetch_NotFound(%v) got: %v, want: %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestAccountUpdate(t *testing.T) {
+	svc := Service{
+		errCtx:       "service",
+		inputMapper:  mapper{},
+		outputMapper: mapper{},
+		updater:      mockOk{assertArg: true, expData: _fullyFilledData},
+	}
+
+	got, err := svc.Update(_idStub, _versionStub, UpdateRequest{Name: _nameStub})
+	if err != nil || !reflect.DeepEqual(got, _fullyFilledEntity) {
+		t.Errorf("Update got: %v, want: %v", got, _fullyFilledEntity)
+	}
+}
+
+func TestUpdate_Error(t *testing.T) {
+	cases := []struct {
+		name string
+		in   Service
+		want error
+	}{
+		{"repo", _serviceWithRequestError, errors.New("service update_repo_update: id: id, version: 1: repo update error")},
+		{"ofAcc", _serviceWithOutputMapperError, errors.New("service update_ofAcc: id: id, version: 1: ofAcc error")},
+	}
+
+	for _, tt := range cases {
+		_, got := tt.in.Update("id", 1, UpdateRequest{})
+		if got.Error() != tt.want.Error() {
+			t.Errorf("Update_Error(%v) got: %v, want: %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestToPatch(t *testing.T) {
+	secondaryIdentification := _secondaryIdentificationStub
+	empty := ""
+	status := Status(_statusStub)
+	version := int64(3)
+	patch := func(att AccountAttributes) *AccountData {
+		return &AccountData{Attributes: &att, ID: _idStub, Type: "accounts", Version: &version}
+	}
+	cases := []struct {
+		name     string
+		in       UpdateRequest
+		want     *AccountData
+		wantJSON string
+	}{
+		{"fully filled", UpdateRequest{
+			Name:                    _nameStub,
+			AlternativeNames:        _alternativeNamesStub,
+			SecondaryIdentification: &secondaryIdentification,
+			Switched:                &_switchedStub,
+			MatchingOptOut:          &_matchingOptOutStub,
+			Status:                  &status,
+		}, patch(AccountAttributes{
+			Name:                    _nameStub,
+			AlternativeNames:        _alternativeNamesStub,
+			SecondaryIdentification: &_secondaryIdentificationStub,
+			Switched:                &_switchedStub,
+			MatchingOptOut:          &_matchingOptOutStub,
+			Status:                  &_statusStub,
+		}), `{"account_matching_opt_out":true,"secondary_identification":"20530441","status":"confirmed","switched":true,"alternative_names":["Adanedhel"],"name":["TURIN TURAMBAR"]}`},
+		{"empty", UpdateRequest{}, patch(AccountAttributes{}), `{}`},
+		{"clear name", UpdateRequest{Name: []string{}}, patch(AccountAttributes{Name: []string{}}), `{"name":[]}`},
+		{"clear alternative names", UpdateRequest{AlternativeNames: []string{}}, patch(AccountAttributes{AlternativeNames: []string{}}), `{"alternative_names":[]}`},
+		{"clear secondary identification", UpdateRequest{SecondaryIdentification: &empty}, patch(AccountAttributes{SecondaryIdentification: &empty}), `{"secondary_identification":""}`},
+	}
+	m := mapper{}
+
+	for _, tt := range cases {
+		got := m.toPatch(_idStub, version, tt.in)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ToPatch(%v) got: %v, want: %v", tt.name, got, tt.want)
+		}
+		if raw, err := json.Marshal(got.Attributes); err != nil || string(raw) != tt.wantJSON {
+			t.Errorf("ToPatch(%v) JSON got: %s, %v, want: %s", tt.name, raw, err, tt.wantJSON)
+		}
+	}
+}
+
 func TestDelete_Error(t *testing.T) {
 	msg := "service delete: id: ad27e265-9605-4b4b-a0e5-3003ea9cc4d2: repo delete error"
 	if got := _serviceWithRequestError.Delete(BuildDeleteRequest(_fakeStubID)); got.Error() != msg {
@@ -322,7 +428,7 @@ func TestDelete_Error(t *testing.T) {
 func TestOfAcc_Error(t *testing.T) {
 	cases := []struct {
 		name string
-		in   data
+		in   AccountData
 		want error
 	}{
 		{"ID parser", _dataWithIDErr, errors.New("ID parse: 3rr0r: invalid UUID length: 5")},
@@ -387,19 +493,35 @@ func TestEntity(t *testing.T) {
 	assert("Switched", entity.Switched(), _switchedStub)
 }
 
-func (m mockErr) create(_ data) (*data, error) {
+func (m mockErr) Create(_ context.Context, _ AccountData) (*AccountData, error) {
 	return nil, errors.New("repo create error")
 }
 
-func (m mockErr) fetch(_ string) (*data, error) {
+func (m mockErr) Fetch(_ context.Context, _ string) (*AccountData, error) {
 	return nil, errors.New("repo fetch error")
 }
 
-func (m mockErr) delete(_ string, _ int64) error {
+func (m mockErr) List(_ context.Context, _ url.Values) (*AccountList, error) {
+	return nil, errors.New("repo list error")
+}
+
+func (m mockErr) Update(_ context.Context, _ AccountData) (*AccountData, error) {
+	return nil, errors.New("repo update error")
+}
+
+func (m mockErr) Delete(_ context.Context, _ string, _ int64) error {
 	return errors.New("repo delete error")
 }
 
-func (m mockOk) create(d data) (*data, error) {
+func (m mockNotFound) Fetch(_ context.Context, id string) (*AccountData, error) {
+	return nil, fmt.Errorf("id: %s: %w", id, ErrNotFound)
+}
+
+func (m mockNilData) Fetch(_ context.Context, _ string) (*AccountData, error) {
+	return nil, nil
+}
+
+func (m mockOk) Create(_ context.Context, d AccountData) (*AccountData, error) {
 	expInput := m.buildData()
 
 	if m.assertArg &&
@@ -414,7 +536,7 @@ func (m mockOk) create(d data) (*data, error) {
 	return &m.expData, nil
 }
 
-func (m mockOk) fetch(id string) (*data, error) {
+func (m mockOk) Fetch(_ context.Context, id string) (*AccountData, error) {
 	expInput := m.buildData()
 
 	if m.assertArg && (expInput.ID != id) {
@@ -424,9 +546,17 @@ func (m mockOk) fetch(id string) (*data, error) {
 	return &m.expData, nil
 }
 
-func (m mockOk) buildData() *data {
-	return &data{
-		Attributes: &attributes{
+func (m mockOk) Update(_ context.Context, d AccountData) (*AccountData, error) {
+	if m.assertArg && (m.expData.ID != d.ID || !reflect.DeepEqual(m.expData.Version, d.Version)) {
+		return nil, errors.New("mockOk expInput did not match with data")
+	}
+
+	return &m.expData, nil
+}
+
+func (m mockOk) buildData() *AccountData {
+	return &AccountData{
+		Attributes: &AccountAttributes{
 			Classification:          m.expData.Attributes.Classification,
 			MatchingOptOut:          m.expData.Attributes.MatchingOptOut,
 			Number:                  m.expData.Attributes.Number,
@@ -449,10 +579,22 @@ func (m mockOk) buildData() *data {
 	}
 }
 
-func (m mockInputMapper) toAcc(_ CreateRequest) *data {
-	return &data{}
+func (m mockInputMapper) toAcc(_ CreateRequest) *AccountData {
+	return &AccountData{}
+}
+
+func (m mockInputMapper) toPatch(_ string, _ int64, _ UpdateRequest) *AccountData {
+	return &AccountData{}
 }
 
-func (m mockOutputMapperErr) ofAcc(data) (*Entity, error) {
+func (m mockInputMapper) toQuery(_ ListOptions) url.Values {
+	return url.Values{}
+}
+
+func (m mockOutputMapperErr) ofAcc(AccountData) (*Entity, error) {
 	return nil, errors.New("ofAcc error")
 }
+
+func (m mockOutputMapperErr) ofPage(AccountList) (*Page, error) {
+	return nil, errors.New("ofPage error")
+}
@@ -0,0 +1,96 @@
+// Package accountprom exposes the account-api calls made by the account package as Prometheus metrics.
+//
+// Example:
+//
+//	collector := accountprom.NewCollector("myapp")
+//	prometheus.MustRegister(collector)
+//	repository := acc.NewHTTPRepository(acc.WithMetrics(collector))
+package accountprom
+
+import (
+	"time"
+
+	account "github.com/r1cm3d/account-lib"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Collector is an account.Metrics that is also a prometheus.Collector. It exposes, labelled by operation:
+//
+//	<namespace>_account_api_requests_total                 counter of finished calls
+//	<namespace>_account_api_errors_total                   counter of failed calls, also labelled by class
+//	<namespace>_account_api_request_duration_seconds       histogram of the duration of calls
+//	<namespace>_account_api_requests_in_flight             gauge of the calls in progress
+type Collector struct {
+	requests *prometheus.CounterVec
+	errors   *prometheus.CounterVec
+	latency  *prometheus.HistogramVec
+	inFlight *prometheus.GaugeVec
+}
+
+const _subsystem = "account_api"
+
+var _ account.Metrics = (*Collector)(nil)
+
+// NewCollector instantiates a Collector whose metric names are prefixed with namespace, if not empty. The duration
+// histogram uses prometheus.DefBuckets.
+func NewCollector(namespace string) *Collector {
+	return &Collector{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: _subsystem,
+			Name:      "requests_total",
+			Help:      "Number of account-api calls, by operation.",
+		}, []string{"operation"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: _subsystem,
+			Name:      "errors_total",
+			Help:      "Number of failed account-api calls, by operation and error class.",
+		}, []string{"operation", "class"}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: _subsystem,
+			Name:      "request_duration_seconds",
+			Help:      "Duration of account-api calls, retries included, by operation.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"operation"}),
+		inFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: _subsystem,
+			Name:      "requests_in_flight",
+			Help:      "Number of account-api calls in progress, by operation.",
+		}, []string{"operation"}),
+	}
+}
+
+// CallStarted implements account.Metrics.
+func (c *Collector) CallStarted(op account.Operation) {
+	c.inFlight.WithLabelValues(string(op)).Inc()
+}
+
+// CallFinished implements account.Metrics.
+func (c *Collector) CallFinished(op account.Operation, d time.Duration, class account.ErrorClass) {
+	c.inFlight.WithLabelValues(string(op)).Dec()
+	c.requests.WithLabelValues(string(op)).Inc()
+	c.latency.WithLabelValues(string(op)).Observe(d.Seconds())
+	if class != account.ErrorClassNone {
+		c.errors.WithLabelValues(string(op), string(class)).Inc()
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	c.requests.Describe(ch)
+	c.errors.Describe(ch)
+	c.latency.Describe(ch)
+	c.inFlight.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.requests.Collect(ch)
+	c.errors.Collect(ch)
+	c.latency.Collect(ch)
+	c.inFlight.Collect(ch)
+}
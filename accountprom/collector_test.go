@@ -0,0 +1,76 @@
+package accountprom
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	account "github.com/r1cm3d/account-lib"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCollector(t *testing.T) {
+	c := NewCollector("test")
+	reg := prometheus.NewPedanticRegistry()
+	if err := reg.Register(c); err != nil {
+		t.Fatal(err)
+	}
+
+	c.CallStarted(account.OperationFetch)
+	c.CallStarted(account.OperationFetch)
+	c.CallFinished(account.OperationFetch, 20*time.Millisecond, account.ErrorClassNone)
+	c.CallStarted(account.OperationCreate)
+	c.CallFinished(account.OperationCreate, 2*time.Second, account.ErrorClassServer)
+
+	cases := []struct {
+		name string
+		in   prometheus.Collector
+		want float64
+	}{
+		{"fetch requests", c.requests.WithLabelValues("fetch"), 1},
+		{"fetch in flight", c.inFlight.WithLabelValues("fetch"), 1},
+		{"create requests", c.requests.WithLabelValues("create"), 1},
+		{"create in flight", c.inFlight.WithLabelValues("create"), 0},
+		{"create server errors", c.errors.WithLabelValues("create", "server"), 1},
+	}
+	for _, tt := range cases {
+		if got := testutil.ToFloat64(tt.in); got != tt.want {
+			t.Errorf("Collector(%v) got: %v, want: %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCollector_Duration(t *testing.T) {
+	c := NewCollector("test")
+	reg := prometheus.NewPedanticRegistry()
+	if err := reg.Register(c); err != nil {
+		t.Fatal(err)
+	}
+
+	c.CallStarted(account.OperationCreate)
+	c.CallFinished(account.OperationCreate, 2*time.Second, account.ErrorClassNone)
+
+	want := `
+# HELP test_account_api_request_duration_seconds Duration of account-api calls, retries included, by operation.
+# TYPE test_account_api_request_duration_seconds histogram
+test_account_api_request_duration_seconds_bucket{operation="create",le="0.005"} 0
+test_account_api_request_duration_seconds_bucket{operation="create",le="0.01"} 0
+test_account_api_request_duration_seconds_bucket{operation="create",le="0.025"} 0
+test_account_api_request_duration_seconds_bucket{operation="create",le="0.05"} 0
+test_account_api_request_duration_seconds_bucket{operation="create",le="0.1"} 0
+test_account_api_request_duration_seconds_bucket{operation="create",le="0.25"} 0
+test_account_api_request_duration_seconds_bucket{operation="create",le="0.5"} 0
+test_account_api_request_duration_seconds_bucket{operation="create",le="1"} 0
+test_account_api_request_duration_seconds_bucket{operation="create",le="2.5"} 1
+test_account_api_request_duration_seconds_bucket{operation="create",le="5"} 1
+test_account_api_request_duration_seconds_bucket{operation="create",le="10"} 1
+test_account_api_request_duration_seconds_bucket{operation="create",le="+Inf"} 1
+test_account_api_request_duration_seconds_sum{operation="create"} 2
+test_account_api_request_duration_seconds_count{operation="create"} 1
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(want), "test_account_api_request_duration_seconds"); err != nil {
+		t.Errorf("Collector_Duration got: %v", err)
+	}
+}
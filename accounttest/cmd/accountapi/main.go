@@ -0,0 +1,22 @@
+// Command accountapi runs the fake account-api of package accounttest, e.g. to run the integration tests without the
+// docker-compose stack:
+//
+//	go run ./accounttest/cmd/accountapi -addr 127.0.0.1:8080 &
+//	go test -run Integration ./... -args -itaddr=127.0.0.1
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+
+	"github.com/r1cm3d/account-lib/accounttest"
+)
+
+func main() {
+	addr := flag.String("addr", "127.0.0.1:8080", "address to listen on")
+	flag.Parse()
+
+	log.Printf("fake account-api listening on %s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, accounttest.NewHandler()))
+}
@@ -0,0 +1,155 @@
+package accounttest
+
+import (
+	"math/rand"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"time"
+)
+
+type (
+	// Fault makes the Server misbehave when answering some requests, e.g. to exercise retries, circuit breaking and
+	// decode failures deterministically. Requests are counted per Route from 1: the Fault applies to the requests
+	// numbered from After+1 to After+Times.
+	//
+	// Example, account-api is unavailable for the first 2 fetch calls:
+	//
+	//	srv.Inject(accounttest.Fault{Route: accounttest.RouteFetch, Times: 2, Status: http.StatusServiceUnavailable})
+	Fault struct {
+		// Route is the endpoint the Fault applies to. It applies to every endpoint when empty, counting the requests
+		// of every endpoint.
+		Route Route
+		// After is the number of requests answered normally before the Fault applies.
+		After int
+		// Times is the number of requests the Fault applies to. It applies to every request after After when zero.
+		Times int
+
+		// Latency delays the response.
+		Latency time.Duration
+		// Jitter adds a random delay between zero and Jitter to Latency.
+		Jitter time.Duration
+		// Reset closes the connection without answering, so the client gets a connection reset. The request is not
+		// handled.
+		Reset bool
+		// Status makes the Server answer with Status and an error body instead of handling the request, e.g. 500, 503
+		// or 429.
+		Status int
+		// Header is added to the response, e.g. Retry-After along with 429.
+		Header http.Header
+		// ContentType replaces the Content-Type of the response.
+		ContentType string
+		// Truncate cuts the response body in half.
+		Truncate bool
+		// Malformed replaces the response body with invalid JSON.
+		Malformed bool
+	}
+)
+
+const _malformedBody = `{"data":{"id":}`
+
+// Inject makes the Server misbehave according to faults, in addition to the ones already injected. When several
+// faults apply to a request, the first one injected is used.
+func (s *Server) Inject(faults ...Fault) {
+	s.h.mu.Lock()
+	defer s.h.mu.Unlock()
+
+	s.h.faults = append(s.h.faults, faults...)
+}
+
+// ClearFaults removes every Fault injected, so the Server answers normally again. Requests are still counted.
+func (s *Server) ClearFaults() {
+	s.h.mu.Lock()
+	defer s.h.mu.Unlock()
+
+	s.h.faults = nil
+}
+
+// Requests returns the number of requests received by route, or by every endpoint when route is empty.
+func (s *Server) Requests(route Route) int {
+	s.h.mu.Lock()
+	defer s.h.mu.Unlock()
+
+	return s.h.requests[route]
+}
+
+// fault counts the request of route and returns the Fault applying to it, if any.
+func (h *handler) fault(route Route) *Fault {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.requests[route]++
+	h.requests[_routeAny]++
+	for _, f := range h.faults {
+		if f.Route != _routeAny && f.Route != route {
+			continue
+		}
+		if n := h.requests[f.Route]; n > f.After && (f.Times == 0 || n <= f.After+f.Times) {
+			return &f
+		}
+	}
+
+	return nil
+}
+
+// serve answers the request according to f. next handles the request normally.
+func (f Fault) serve(w http.ResponseWriter, r *http.Request, next func(w http.ResponseWriter)) {
+	delay := f.Latency
+	if f.Jitter > 0 {
+		delay += time.Duration(rand.Int63n(int64(f.Jitter)))
+	}
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	if f.Reset {
+		reset(w)
+		return
+	}
+
+	rec := httptest.NewRecorder()
+	if f.Status != 0 {
+		writeError(rec, f.Status, http.StatusText(f.Status))
+	} else {
+		next(rec)
+	}
+
+	body := rec.Body.Bytes()
+	switch {
+	case f.Malformed:
+		body = []byte(_malformedBody)
+	case f.Truncate:
+		body = body[:len(body)/2]
+	}
+	header := w.Header()
+	for k, v := range rec.Header() {
+		header[k] = v
+	}
+	for k, v := range f.Header {
+		header[k] = v
+	}
+	if f.ContentType != "" {
+		header.Set("Content-Type", f.ContentType)
+	}
+
+	w.WriteHeader(rec.Code)
+	_, _ = w.Write(body)
+}
+
+// reset closes the connection of w, discarding unsent data so the client gets a connection reset.
+func reset(w http.ResponseWriter) {
+	conn, _, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		panic(http.ErrAbortHandler)
+	}
+	if tcp, ok := conn.(*net.TCPConn); ok {
+		_ = tcp.SetLinger(0)
+	}
+	_ = conn.Close()
+}
@@ -0,0 +1,152 @@
+package accounttest
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	acc "github.com/r1cm3d/account-lib"
+
+	"github.com/pkg/errors"
+)
+
+func TestServer_Inject(t *testing.T) {
+	cases := []struct {
+		name            string
+		fault           Fault
+		wantStatus      int
+		wantBody        string
+		wantContentType string
+		wantDecodeErr   bool
+	}{
+		{"status", Fault{Status: 503}, 503, `{"error_message":"Service Unavailable"}`, _contentType, false},
+		{"header", Fault{Status: 429, Header: http.Header{"Retry-After": {"1"}}}, 429, `{"error_message":"Too Many Requests"}`, _contentType, false},
+		{"content type", Fault{ContentType: "text/html"}, 200, `{"status":"up"}`, "text/html", false},
+		{"malformed", Fault{Malformed: true}, 200, _malformedBody, _contentType, true},
+		{"truncated", Fault{Truncate: true}, 200, `{"status`, _contentType, true},
+		{"truncated error", Fault{Status: 500, Truncate: true}, 500, `{"error_message":"In`, _contentType, true},
+		{"other route", Fault{Route: RouteFetch, Status: 500}, 200, `{"status":"up"}`, _contentType, false},
+	}
+
+	for _, tt := range cases {
+		srv := NewServer()
+		srv.Inject(tt.fault)
+
+		resp, err := http.Get(srv.URL + _healthPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		srv.Close()
+
+		var v interface{}
+		decodeErr := json.Unmarshal(body, &v) != nil
+		if resp.StatusCode != tt.wantStatus || !strings.HasPrefix(string(body), tt.wantBody) || decodeErr != tt.wantDecodeErr {
+			t.Errorf("Server_Inject(%v) got: %d %s, want: %d %s", tt.name, resp.StatusCode, body, tt.wantStatus, tt.wantBody)
+		}
+		if got := resp.Header.Get("Content-Type"); got != tt.wantContentType {
+			t.Errorf("Server_Inject(%v) Content-Type got: %v, want: %v", tt.name, got, tt.wantContentType)
+		}
+		for k := range tt.fault.Header {
+			if resp.Header.Get(k) != tt.fault.Header.Get(k) {
+				t.Errorf("Server_Inject(%v) %s got: %v, want: %v", tt.name, k, resp.Header.Get(k), tt.fault.Header.Get(k))
+			}
+		}
+	}
+}
+
+func TestServer_InjectCount(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	srv.Inject(
+		Fault{Route: RouteHealth, After: 1, Times: 2, Status: 500},
+		Fault{Route: RouteHealth, Status: 503},
+	)
+
+	var got []int
+	for i := 0; i < 4; i++ {
+		resp, err := http.Get(srv.URL + _healthPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		got = append(got, resp.StatusCode)
+	}
+	srv.ClearFaults()
+	resp, err := http.Get(srv.URL + _healthPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	got = append(got, resp.StatusCode)
+
+	want := []int{503, 500, 500, 503, 200}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Server_InjectCount got: %v, want: %v", got, want)
+			break
+		}
+	}
+	if srv.Requests(RouteHealth) != 5 || srv.Requests(RouteFetch) != 0 || srv.Requests("") != 5 {
+		t.Errorf("Server_InjectCount requests got: %d health, %d fetch, want: 5 health, 0 fetch", srv.Requests(RouteHealth), srv.Requests(RouteFetch))
+	}
+}
+
+func TestServer_InjectLatency(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	srv.Inject(Fault{Latency: 20 * time.Millisecond, Jitter: 10 * time.Millisecond})
+
+	start := time.Now()
+	resp, err := http.Get(srv.URL + _healthPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if got := time.Since(start); got < 20*time.Millisecond || resp.StatusCode != 200 {
+		t.Errorf("Server_InjectLatency got: %d after %v, want: 200 after 20ms", resp.StatusCode, got)
+	}
+}
+
+func TestServer_InjectReset(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	srv.Inject(Fault{Reset: true, Times: 1})
+
+	if _, err := http.Get(srv.URL + _healthPath); err == nil {
+		t.Errorf("Server_InjectReset got: nil, want: connection error")
+	}
+	resp, err := http.Get(srv.URL + _healthPath)
+	if err != nil || resp.StatusCode != 200 {
+		t.Errorf("Server_InjectReset after fault got: %v, want: 200", err)
+	}
+}
+
+func TestServer_InjectService(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	baseURL, _ := url.Parse(srv.URL)
+	retry := acc.WithRetryPolicy(acc.RetryPolicy{BaseDelay: time.Millisecond})
+	svc := acc.NewService(acc.NewHTTPRepository(acc.WithBaseURL(baseURL), retry))
+	if _, err := svc.Create(acc.CreateRequest{ID: _idStub, OrganisationID: _organisationIDStub, Country: "GB", Name: []string{"BRUCE WAYNE"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	srv.Inject(Fault{Route: RouteFetch, Times: 2, Status: 503})
+	if _, err := svc.Fetch(_idStub); err != nil || srv.Requests(RouteFetch) != 3 {
+		t.Errorf("Server_InjectService(retried) got: %v after %d requests, want: nil after 3 requests", err, srv.Requests(RouteFetch))
+	}
+
+	srv.Inject(Fault{Route: RouteFetch, Truncate: true})
+	_, err := svc.Fetch(_idStub)
+	var apiErr *acc.APIError
+	if err == nil || errors.As(err, &apiErr) || !strings.Contains(err.Error(), "parseSuccess() decode") {
+		t.Errorf("Server_InjectService(truncated) got: %v, want: decode error", err)
+	}
+}
@@ -0,0 +1,302 @@
+// Package repositorytest checks that an account.Repository behaves like account-api, e.g. an in-house caching layer,
+// a fake or another transport.
+package repositorytest
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+
+	acc "github.com/r1cm3d/account-lib"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+// deleteRequest is an acc.DeleteRequest with a version of choice.
+type deleteRequest struct {
+	id      string
+	version int64
+}
+
+const _conformanceOrganisationID = "eb0bd6f5-c3f5-44b2-b677-acd23cdde73c"
+
+// RunConformance checks that the Repository returned by factory behaves like account-api, through an account.Service
+// so attributes round trip through the mappers. Each check runs as a subtest with a Repository of its own:
+//
+// Create keeps every attribute of CreateRequest, starts at version 0 and fails with ErrConflict on a duplicate ID.
+//
+// Fetch returns the account as created and fails with ErrNotFound on an unknown ID.
+//
+// Update changes the given attributes only, clears the ones given empty, increments the version and fails with
+// ErrVersionConflict on a stale version and with ErrNotFound on an unknown ID.
+//
+// Delete removes the account and fails with ErrVersionConflict on a stale version and with ErrNotFound on an unknown
+// ID.
+//
+// List pages through the accounts matching a filter.
+//
+// Accounts are created with random IDs and a random bank ID, so the checks can run against a shared account-api.
+//
+// Example:
+//
+//	func TestCachingRepository(t *testing.T) {
+//		repositorytest.RunConformance(t, func(t *testing.T) acc.Repository {
+//			return NewCachingRepository(acc.NewInMemoryRepository())
+//		})
+//	}
+func RunConformance(t *testing.T, factory func(t *testing.T) acc.Repository) {
+	t.Helper()
+
+	checks := []struct {
+		name  string
+		check func(t *testing.T, svc *acc.Service)
+	}{
+		{"create", conformCreate},
+		{"create duplicate", conformCreateDuplicate},
+		{"fetch", conformFetch},
+		{"fetch not found", conformFetchNotFound},
+		{"update", conformUpdate},
+		{"update clear", conformUpdateClear},
+		{"update version conflict", conformUpdateVersionConflict},
+		{"update not found", conformUpdateNotFound},
+		{"delete", conformDelete},
+		{"delete version conflict", conformDeleteVersionConflict},
+		{"delete not found", conformDeleteNotFound},
+		{"list", conformList},
+	}
+
+	for _, c := range checks {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			c.check(t, acc.NewService(factory(t)))
+		})
+	}
+}
+
+func conformCreate(t *testing.T, svc *acc.Service) {
+	cr := conformanceRequest()
+
+	got, err := svc.Create(cr)
+	if err != nil {
+		t.Fatalf("RepositoryConformance(create) got: %v, want: nil", err)
+	}
+	if got.Version() != 0 {
+		t.Errorf("RepositoryConformance(create) version got: %v, want: 0", got.Version())
+	}
+	conformAttributes(t, "create", got, cr)
+}
+
+func conformCreateDuplicate(t *testing.T, svc *acc.Service) {
+	cr := conformCreated(t, svc)
+
+	if _, err := svc.Create(cr); !errors.Is(err, acc.ErrConflict) {
+		t.Errorf("RepositoryConformance(create duplicate) got: %v, want: %v", err, acc.ErrConflict)
+	}
+}
+
+func conformFetch(t *testing.T, svc *acc.Service) {
+	cr := conformCreated(t, svc)
+
+	got, err := svc.Fetch(cr.ID)
+	if err != nil {
+		t.Fatalf("RepositoryConformance(fetch) got: %v, want: nil", err)
+	}
+	if got.Version() != 0 {
+		t.Errorf("RepositoryConformance(fetch) version got: %v, want: 0", got.Version())
+	}
+	conformAttributes(t, "fetch", got, cr)
+}
+
+func conformFetchNotFound(t *testing.T, svc *acc.Service) {
+	if _, err := svc.Fetch(uuid.NewString()); !errors.Is(err, acc.ErrNotFound) {
+		t.Errorf("RepositoryConformance(fetch not found) got: %v, want: %v", err, acc.ErrNotFound)
+	}
+}
+
+func conformUpdate(t *testing.T, svc *acc.Service) {
+	cr := conformCreated(t, svc)
+	ur := acc.UpdateRequest{Name: []string{"Jane", "Doe"}, AlternativeNames: []string{"J Doe"}}
+
+	got, err := svc.Update(cr.ID, 0, ur)
+	if err != nil {
+		t.Fatalf("RepositoryConformance(update) got: %v, want: nil", err)
+	}
+	if got.Version() != 1 {
+		t.Errorf("RepositoryConformance(update) version got: %v, want: 1", got.Version())
+	}
+	cr.Name, cr.AlternativeNames = ur.Name, ur.AlternativeNames
+	conformAttributes(t, "update", got, cr)
+
+	fetched, err := svc.Fetch(cr.ID)
+	if err != nil || fetched.Version() != 1 {
+		t.Fatalf("RepositoryConformance(update fetch) got: %+v, %v, want: version 1", fetched, err)
+	}
+	conformAttributes(t, "update fetch", fetched, cr)
+}
+
+func conformUpdateClear(t *testing.T, svc *acc.Service) {
+	cr := conformCreated(t, svc)
+	empty := ""
+	ur := acc.UpdateRequest{AlternativeNames: []string{}, SecondaryIdentification: &empty}
+
+	got, err := svc.Update(cr.ID, 0, ur)
+	if err != nil {
+		t.Fatalf("RepositoryConformance(update clear) got: %v, want: nil", err)
+	}
+	cr.AlternativeNames, cr.SecondaryIdentification = []string{}, ""
+	conformAttributes(t, "update clear", got, cr)
+}
+
+func conformUpdateVersionConflict(t *testing.T, svc *acc.Service) {
+	cr := conformCreated(t, svc)
+
+	if _, err := svc.Update(cr.ID, 1, acc.UpdateRequest{Name: []string{"Jane Doe"}}); !errors.Is(err, acc.ErrVersionConflict) {
+		t.Errorf("RepositoryConformance(update version conflict) got: %v, want: %v", err, acc.ErrVersionConflict)
+	}
+}
+
+func conformUpdateNotFound(t *testing.T, svc *acc.Service) {
+	if _, err := svc.Update(uuid.NewString(), 0, acc.UpdateRequest{Name: []string{"Jane Doe"}}); !errors.Is(err, acc.ErrNotFound) {
+		t.Errorf("RepositoryConformance(update not found) got: %v, want: %v", err, acc.ErrNotFound)
+	}
+}
+
+func conformDelete(t *testing.T, svc *acc.Service) {
+	cr := conformCreated(t, svc)
+
+	if err := svc.Delete(acc.BuildDeleteRequest(cr.ID)); err != nil {
+		t.Fatalf("RepositoryConformance(delete) got: %v, want: nil", err)
+	}
+	if _, err := svc.Fetch(cr.ID); !errors.Is(err, acc.ErrNotFound) {
+		t.Errorf("RepositoryConformance(delete fetch) got: %v, want: %v", err, acc.ErrNotFound)
+	}
+}
+
+func conformDeleteVersionConflict(t *testing.T, svc *acc.Service) {
+	cr := conformCreated(t, svc)
+	stale := deleteRequest{id: cr.ID, version: 1}
+
+	if err := svc.Delete(stale); !errors.Is(err, acc.ErrVersionConflict) {
+		t.Errorf("RepositoryConformance(delete version conflict) got: %v, want: %v", err, acc.ErrVersionConflict)
+	}
+	if _, err := svc.Fetch(cr.ID); err != nil {
+		t.Errorf("RepositoryConformance(delete version conflict fetch) got: %v, want: nil", err)
+	}
+}
+
+func conformDeleteNotFound(t *testing.T, svc *acc.Service) {
+	if err := svc.Delete(acc.BuildDeleteRequest(uuid.NewString())); !errors.Is(err, acc.ErrNotFound) {
+		t.Errorf("RepositoryConformance(delete not found) got: %v, want: %v", err, acc.ErrNotFound)
+	}
+}
+
+func conformList(t *testing.T, svc *acc.Service) {
+	bankID := conformanceBankID()
+	var want []string
+	for i := 0; i < 3; i++ {
+		cr := conformanceRequest()
+		cr.BankID = bankID
+		if _, err := svc.Create(cr); err != nil {
+			t.Fatalf("RepositoryConformance(list create) got: %v, want: nil", err)
+		}
+		want = append(want, cr.ID)
+	}
+
+	var got []string
+	pages := 0
+	opts := &acc.ListOptions{PageSize: 2, Filter: acc.ListFilter{BankID: bankID}}
+	for opts != nil && pages <= len(want) {
+		page, err := svc.List(*opts)
+		if err != nil {
+			t.Fatalf("RepositoryConformance(list) got: %v, want: nil", err)
+		}
+		if len(page.Accounts) > opts.PageSize {
+			t.Errorf("RepositoryConformance(list) page size got: %d, want: at most %d", len(page.Accounts), opts.PageSize)
+		}
+		for _, a := range page.Accounts {
+			got = append(got, a.ID())
+		}
+		opts, pages = page.Next, pages+1
+	}
+
+	sort.Strings(got)
+	sort.Strings(want)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("RepositoryConformance(list) got: %v, want: %v", got, want)
+	}
+}
+
+// conformCreated creates an account and returns the CreateRequest used.
+func conformCreated(t *testing.T, svc *acc.Service) acc.CreateRequest {
+	t.Helper()
+
+	cr := conformanceRequest()
+	if _, err := svc.Create(cr); err != nil {
+		t.Fatalf("RepositoryConformance(create) got: %v, want: nil", err)
+	}
+
+	return cr
+}
+
+// conformAttributes checks that the attributes of got are the ones of want.
+func conformAttributes(t *testing.T, name string, got *acc.Entity, want acc.CreateRequest) {
+	t.Helper()
+
+	attributes := acc.CreateRequest{
+		ID:                      got.ID(),
+		OrganisationID:          got.OrganisationID().String(),
+		Classification:          string(got.Classification()),
+		MatchingOptOut:          got.MatchingOptOut(),
+		Number:                  got.Number(),
+		AlternativeNames:        got.AlternativeNames(),
+		BankID:                  got.BankID(),
+		BankIDCode:              got.BankIDCode(),
+		BaseCurrency:            string(got.BaseCurrency()),
+		Bic:                     got.Bic(),
+		Country:                 string(got.Country()),
+		Iban:                    got.Iban(),
+		JointAccount:            got.JointAccount(),
+		Name:                    got.Name(),
+		SecondaryIdentification: got.SecondaryIdentification(),
+		Switched:                got.Switched(),
+	}
+	if !reflect.DeepEqual(attributes, want) {
+		t.Errorf("RepositoryConformance(%s) attributes got: %+v, want: %+v", name, attributes, want)
+	}
+}
+
+func conformanceRequest() acc.CreateRequest {
+	return acc.CreateRequest{
+		ID:                      uuid.NewString(),
+		OrganisationID:          _conformanceOrganisationID,
+		Classification:          "Personal",
+		MatchingOptOut:          true,
+		Number:                  "41426819",
+		AlternativeNames:        []string{"Sam Holder"},
+		BankID:                  conformanceBankID(),
+		BankIDCode:              "GBDSC",
+		BaseCurrency:            "GBP",
+		Bic:                     "NWBKGB22",
+		Country:                 "GB",
+		Iban:                    "GB11NWBK40030041426819",
+		JointAccount:            true,
+		Name:                    []string{"Samantha Holder"},
+		SecondaryIdentification: "A1B2C3D4",
+		Switched:                true,
+	}
+}
+
+func conformanceBankID() string {
+	return fmt.Sprintf("%06d", rand.Intn(1000000))
+}
+
+func (d deleteRequest) ID() string {
+	return d.id
+}
+
+func (d deleteRequest) Version() int64 {
+	return d.version
+}
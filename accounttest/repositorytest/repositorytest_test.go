@@ -0,0 +1,76 @@
+package repositorytest
+
+import (
+	"context"
+	"net/url"
+	"sync"
+	"testing"
+
+	acc "github.com/r1cm3d/account-lib"
+	"github.com/r1cm3d/account-lib/accounttest"
+)
+
+// cachingRepository serves fetches from a cache filled by the Repository it wraps, as an in-house caching layer would.
+// Being outside the account package, it shows Repository can be implemented by its users.
+type cachingRepository struct {
+	acc.Repository
+
+	mu    sync.Mutex
+	cache map[string]acc.AccountData
+}
+
+var _ acc.Repository = (*cachingRepository)(nil)
+
+func TestRunConformance_InMemory(t *testing.T) {
+	RunConformance(t, func(t *testing.T) acc.Repository {
+		return acc.NewInMemoryRepository()
+	})
+}
+
+func TestRunConformance_HTTP(t *testing.T) {
+	RunConformance(t, func(t *testing.T) acc.Repository {
+		srv := accounttest.NewServer()
+		t.Cleanup(srv.Close)
+		baseURL, _ := url.Parse(srv.URL)
+
+		return acc.NewHTTPRepository(acc.WithBaseURL(baseURL))
+	})
+}
+
+func TestRunConformance_Caching(t *testing.T) {
+	RunConformance(t, func(t *testing.T) acc.Repository {
+		return &cachingRepository{Repository: acc.NewInMemoryRepository(), cache: map[string]acc.AccountData{}}
+	})
+}
+
+func (r *cachingRepository) Fetch(ctx context.Context, id string) (*acc.AccountData, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if cached, ok := r.cache[id]; ok {
+		return &cached, nil
+	}
+
+	d, err := r.Repository.Fetch(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	r.cache[id] = *d
+
+	return d, nil
+}
+
+func (r *cachingRepository) Update(ctx context.Context, d acc.AccountData) (*acc.AccountData, error) {
+	r.forget(d.ID)
+	return r.Repository.Update(ctx, d)
+}
+
+func (r *cachingRepository) Delete(ctx context.Context, id string, version int64) error {
+	r.forget(id)
+	return r.Repository.Delete(ctx, id, version)
+}
+
+func (r *cachingRepository) forget(id string) {
+	r.mu.Lock()
+	delete(r.cache, id)
+	r.mu.Unlock()
+}
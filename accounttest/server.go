@@ -0,0 +1,413 @@
+// Package accounttest provides a fake account-api keeping accounts in memory, e.g. to run integration tests without
+// the docker-compose stack. It implements /v1/organisation/accounts and /v1/health with the status codes and error
+// bodies of account-api.
+//
+// Example:
+//
+//	srv := accounttest.NewServer()
+//	defer srv.Close()
+//	baseURL, _ := url.Parse(srv.URL)
+//	svc := acc.NewService(acc.NewHTTPRepository(acc.WithBaseURL(baseURL)))
+package accounttest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type (
+	// Route identifies an endpoint of the fake account-api by its method and path.
+	Route string
+	// Server is a fake account-api listening on a system-chosen port on the loopback interface. Its URL is the base
+	// URL of account-api. Faults can be injected to make it misbehave, see Fault.
+	Server struct {
+		*httptest.Server
+
+		h *handler
+	}
+	handler struct {
+		mu       sync.Mutex
+		accounts map[string]*account
+		ids      []string
+		faults   []Fault
+		requests map[Route]int
+	}
+	account struct {
+		ID             string                     `json:"id"`
+		OrganisationID string                     `json:"organisation_id"`
+		Type           string                     `json:"type"`
+		Version        *int64                     `json:"version"`
+		CreatedOn      string                     `json:"created_on,omitempty"`
+		ModifiedOn     string                     `json:"modified_on,omitempty"`
+		Attributes     map[string]json.RawMessage `json:"attributes"`
+	}
+	payload struct {
+		Data  *account `json:"data"`
+		Links *links   `json:"links,omitempty"`
+	}
+	listPayload struct {
+		Data  []*account `json:"data"`
+		Links *links     `json:"links"`
+	}
+	links struct {
+		First string `json:"first,omitempty"`
+		Last  string `json:"last,omitempty"`
+		Next  string `json:"next,omitempty"`
+		Prev  string `json:"prev,omitempty"`
+		Self  string `json:"self"`
+	}
+	errorBody struct {
+		Message string `json:"error_message"`
+	}
+)
+
+const (
+	_accountsPath    = "/v1/organisation/accounts"
+	_healthPath      = "/v1/health"
+	_contentType     = "application/vnd.api+json"
+	_defaultPageSize = 100
+	_pageNumberParam = "page[number]"
+	_pageSizeParam   = "page[size]"
+)
+
+const (
+	// RouteCreate is the endpoint creating an account.
+	RouteCreate Route = "POST /v1/organisation/accounts"
+	// RouteList is the endpoint listing accounts.
+	RouteList Route = "GET /v1/organisation/accounts"
+	// RouteFetch is the endpoint fetching an account.
+	RouteFetch Route = "GET /v1/organisation/accounts/{id}"
+	// RouteUpdate is the endpoint updating an account.
+	RouteUpdate Route = "PATCH /v1/organisation/accounts/{id}"
+	// RouteDelete is the endpoint deleting an account.
+	RouteDelete Route = "DELETE /v1/organisation/accounts/{id}"
+	// RouteHealth is the health check endpoint.
+	RouteHealth Route = "GET /v1/health"
+
+	_routeMethodNotAllowed Route = "method not allowed"
+	_routeNotFound         Route = "not found"
+	_routeAny              Route = ""
+)
+
+var _country = regexp.MustCompile(`^[A-Z]{2}$`)
+
+// NewServer starts a Server without accounts. It must be closed by the caller.
+func NewServer() *Server {
+	h := newHandler()
+	return &Server{Server: httptest.NewServer(h), h: h}
+}
+
+// NewHandler returns the http.Handler of a fake account-api without accounts, e.g. to listen on a given address:
+//
+//	log.Fatal(http.ListenAndServe("127.0.0.1:8080", accounttest.NewHandler()))
+func NewHandler() http.Handler {
+	return newHandler()
+}
+
+func newHandler() *handler {
+	return &handler{accounts: map[string]*account{}, requests: map[Route]int{}}
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	route, id := routeOf(r)
+	if f := h.fault(route); f != nil {
+		f.serve(w, r, func(w http.ResponseWriter) { h.serve(w, r, route, id) })
+		return
+	}
+
+	h.serve(w, r, route, id)
+}
+
+func (h *handler) serve(w http.ResponseWriter, r *http.Request, route Route, id string) {
+	switch route {
+	case RouteHealth:
+		writeJSON(w, http.StatusOK, map[string]string{"status": "up"})
+	case RouteCreate:
+		h.create(w, r)
+	case RouteList:
+		h.list(w, r)
+	case RouteFetch:
+		h.fetch(w, id)
+	case RouteUpdate:
+		h.update(w, r, id)
+	case RouteDelete:
+		h.delete(w, r, id)
+	case _routeMethodNotAllowed:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+	}
+}
+
+// routeOf returns the Route of r and the account ID in its path, if any.
+func routeOf(r *http.Request) (Route, string) {
+	path := strings.TrimSuffix(r.URL.Path, "/")
+	id, isAccount := strings.CutPrefix(path, _accountsPath+"/")
+	isAccount = isAccount && !strings.Contains(id, "/")
+
+	switch {
+	case path == _healthPath && r.Method == http.MethodGet:
+		return RouteHealth, ""
+	case path == _accountsPath && r.Method == http.MethodPost:
+		return RouteCreate, ""
+	case path == _accountsPath && r.Method == http.MethodGet:
+		return RouteList, ""
+	case isAccount && r.Method == http.MethodGet:
+		return RouteFetch, id
+	case isAccount && r.Method == http.MethodPatch:
+		return RouteUpdate, id
+	case isAccount && r.Method == http.MethodDelete:
+		return RouteDelete, id
+	case path == _healthPath || path == _accountsPath || isAccount:
+		return _routeMethodNotAllowed, ""
+	default:
+		return _routeNotFound, ""
+	}
+}
+
+func (h *handler) create(w http.ResponseWriter, r *http.Request) {
+	var body payload
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Data == nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	acc := body.Data
+	if failures := validate(acc); len(failures) > 0 {
+		writeError(w, http.StatusBadRequest, "validation failure list:\n"+strings.Join(failures, "\n"))
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.accounts[acc.ID]; ok {
+		writeError(w, http.StatusConflict, "Account cannot be created as it violates a duplicate constraint")
+		return
+	}
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	version := int64(0)
+	acc.Version, acc.CreatedOn, acc.ModifiedOn = &version, now, now
+	h.accounts[acc.ID] = acc
+	h.ids = append(h.ids, acc.ID)
+
+	writeJSON(w, http.StatusCreated, payload{Data: acc, Links: &links{Self: _accountsPath + "/" + acc.ID}})
+}
+
+func (h *handler) fetch(w http.ResponseWriter, id string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	acc, ok := h.find(w, id)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, payload{Data: acc, Links: &links{Self: _accountsPath + "/" + id}})
+}
+
+func (h *handler) update(w http.ResponseWriter, r *http.Request, id string) {
+	var body payload
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Data == nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	patch := body.Data
+	if patch.ID != "" && patch.ID != id {
+		writeError(w, http.StatusBadRequest, "id in body does not match id in path")
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	acc, ok := h.find(w, id)
+	if !ok {
+		return
+	}
+	if patch.Version != nil && *patch.Version != *acc.Version {
+		writeError(w, http.StatusConflict, "invalid version")
+		return
+	}
+
+	for k, v := range patch.Attributes {
+		if string(v) != "null" {
+			acc.Attributes[k] = v
+		}
+	}
+	version := *acc.Version + 1
+	acc.Version, acc.ModifiedOn = &version, time.Now().UTC().Format(time.RFC3339Nano)
+
+	writeJSON(w, http.StatusOK, payload{Data: acc, Links: &links{Self: _accountsPath + "/" + id}})
+}
+
+func (h *handler) delete(w http.ResponseWriter, r *http.Request, id string) {
+	version, err := strconv.ParseInt(r.URL.Query().Get("version"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid version number")
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	acc, ok := h.find(w, id)
+	if !ok {
+		return
+	}
+	if *acc.Version != version {
+		writeError(w, http.StatusConflict, "invalid version")
+		return
+	}
+
+	delete(h.accounts, id)
+	for i, v := range h.ids {
+		if v == id {
+			h.ids = append(h.ids[:i], h.ids[i+1:]...)
+			break
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *handler) list(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	number, size := 0, _defaultPageSize
+	var err error
+	if v := query.Get(_pageNumberParam); v != "" {
+		if number, err = strconv.Atoi(v); err != nil || number < 0 {
+			writeError(w, http.StatusBadRequest, "invalid page number")
+			return
+		}
+	}
+	if v := query.Get(_pageSizeParam); v != "" {
+		if size, err = strconv.Atoi(v); err != nil || size <= 0 {
+			writeError(w, http.StatusBadRequest, "invalid page size")
+			return
+		}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	matches := make([]*account, 0, len(h.ids))
+	for _, id := range h.ids {
+		if acc := h.accounts[id]; matchFilter(acc, query) {
+			matches = append(matches, acc)
+		}
+	}
+
+	data := []*account{}
+	for i := number * size; i < len(matches) && len(data) < size; i++ {
+		data = append(data, matches[i])
+	}
+
+	last := 0
+	if len(matches) > 0 {
+		last = (len(matches) - 1) / size
+	}
+	pageLink := func(n int) string {
+		page := url.Values{}
+		for k, v := range query {
+			page[k] = v
+		}
+		page.Set(_pageNumberParam, strconv.Itoa(n))
+		page.Set(_pageSizeParam, strconv.Itoa(size))
+		return _accountsPath + "?" + page.Encode()
+	}
+	l := &links{First: pageLink(0), Last: pageLink(last), Self: pageLink(number)}
+	if number < last {
+		l.Next = pageLink(number + 1)
+	}
+	if number > 0 {
+		l.Prev = pageLink(number - 1)
+	}
+
+	writeJSON(w, http.StatusOK, listPayload{Data: data, Links: l})
+}
+
+// find returns the account with id or writes the error response. h.mu must be held.
+func (h *handler) find(w http.ResponseWriter, id string) (*account, bool) {
+	if _, err := uuid.Parse(id); err != nil {
+		writeError(w, http.StatusBadRequest, "id is not a valid uuid")
+		return nil, false
+	}
+	acc, ok := h.accounts[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("record %s does not exist", id))
+		return nil, false
+	}
+
+	return acc, true
+}
+
+func validate(acc *account) []string {
+	var failures []string
+	fail := func(format string, args ...interface{}) {
+		failures = append(failures, fmt.Sprintf(format, args...))
+	}
+
+	for _, f := range []struct{ name, value string }{{"id", acc.ID}, {"organisation_id", acc.OrganisationID}} {
+		if f.value == "" {
+			fail("%s in body is required", f.name)
+		} else if _, err := uuid.Parse(f.value); err != nil {
+			fail("%s in body must be of type uuid: %q", f.name, f.value)
+		}
+	}
+	if acc.Type != "accounts" {
+		fail(`type in body should be one of [accounts]`)
+	}
+	if acc.Version != nil && *acc.Version < 0 {
+		fail("version in body should be greater than or equal to 0")
+	}
+	if acc.Attributes == nil {
+		fail("attributes in body is required")
+		return failures
+	}
+
+	var country string
+	if err := json.Unmarshal(acc.Attributes["country"], &country); err != nil || country == "" {
+		fail("country in body is required")
+	} else if !_country.MatchString(country) {
+		fail(`country in body should match '^[A-Z]{2}$'`)
+	}
+
+	return failures
+}
+
+// matchFilter reports whether every filter[<attribute>] parameter of query matches the attribute of acc.
+func matchFilter(acc *account, query url.Values) bool {
+	for k := range query {
+		name, ok := strings.CutPrefix(k, "filter[")
+		if !ok || !strings.HasSuffix(name, "]") {
+			continue
+		}
+		var v string
+		_ = json.Unmarshal(acc.Attributes[strings.TrimSuffix(name, "]")], &v)
+		if v != query.Get(k) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", _contentType)
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, errorBody{Message: msg})
+}
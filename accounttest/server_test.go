@@ -0,0 +1,151 @@
+package accounttest
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	acc "github.com/r1cm3d/account-lib"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+const (
+	_idStub             = "ad27e265-9605-4b4b-a0e5-3003ea9cc4d2"
+	_unknownIDStub      = "eed9954a-a58b-4f59-b44f-8d0592748d53"
+	_organisationIDStub = "eb0bd6f5-c3f5-44b2-b677-acd23cdde73c"
+	_accountBodyStub    = `{"data":{"id":"` + _idStub + `","organisation_id":"` + _organisationIDStub + `","type":"accounts","attributes":{"country":"GB","bank_id":"400300","name":["BRUCE","WAYNE"]}}}`
+)
+
+func TestServer(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+
+	cases := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+		wantBody   string
+	}{
+		{"health", http.MethodGet, _healthPath, "", 200, `{"status":"up"}`},
+		{"create", http.MethodPost, _accountsPath, _accountBodyStub, 201, `"version":0`},
+		{"create duplicate", http.MethodPost, _accountsPath, _accountBodyStub, 409, `{"error_message":"Account cannot be created as it violates a duplicate constraint"}`},
+		{"create invalid", http.MethodPost, _accountsPath, `{"data":{"type":"accounts"}}`, 400, `{"error_message":"validation failure list:\nid in body is required\norganisation_id in body is required\nattributes in body is required"}`},
+		{"create invalid country", http.MethodPost, _accountsPath, strings.Replace(_accountBodyStub, `"GB"`, `"gb"`, 1), 400, `country in body should match`},
+		{"create malformed", http.MethodPost, _accountsPath, `{`, 400, `{"error_message":"invalid request body"}`},
+		{"fetch", http.MethodGet, _accountsPath + "/" + _idStub, "", 200, `"bank_id":"400300"`},
+		{"fetch unknown", http.MethodGet, _accountsPath + "/" + _unknownIDStub, "", 404, `{"error_message":"record ` + _unknownIDStub + ` does not exist"}`},
+		{"fetch invalid ID", http.MethodGet, _accountsPath + "/666", "", 400, `{"error_message":"id is not a valid uuid"}`},
+		{"update stale version", http.MethodPatch, _accountsPath + "/" + _idStub, `{"data":{"version":1,"attributes":{"bank_id":"1"}}}`, 409, `{"error_message":"invalid version"}`},
+		{"update", http.MethodPatch, _accountsPath + "/" + _idStub, `{"data":{"version":0,"attributes":{"bank_id":"1"}}}`, 200, `"version":1`},
+		{"list", http.MethodGet, _accountsPath + "?filter%5Bbank_id%5D=1", "", 200, `"bank_id":"1"`},
+		{"list invalid page", http.MethodGet, _accountsPath + "?page%5Bnumber%5D=x", "", 400, `{"error_message":"invalid page number"}`},
+		{"delete stale version", http.MethodDelete, _accountsPath + "/" + _idStub + "?version=0", "", 409, `{"error_message":"invalid version"}`},
+		{"delete without version", http.MethodDelete, _accountsPath + "/" + _idStub, "", 400, `{"error_message":"invalid version number"}`},
+		{"delete", http.MethodDelete, _accountsPath + "/" + _idStub + "?version=1", "", 204, ``},
+		{"delete unknown", http.MethodDelete, _accountsPath + "/" + _idStub + "?version=1", "", 404, `does not exist`},
+		{"method not allowed", http.MethodPut, _accountsPath + "/" + _idStub, "", 405, `{"error_message":"method not allowed"}`},
+		{"not found", http.MethodGet, "/v1/unknown", "", 404, `{"error_message":"not found"}`},
+	}
+
+	for _, tt := range cases {
+		req, _ := http.NewRequest(tt.method, srv.URL+tt.path, strings.NewReader(tt.body))
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		if resp.StatusCode != tt.wantStatus || !strings.Contains(string(body), tt.wantBody) {
+			t.Errorf("Server(%v) got: %d %s, want: %d %s", tt.name, resp.StatusCode, body, tt.wantStatus, tt.wantBody)
+		}
+		if tt.wantStatus != http.StatusNoContent && resp.Header.Get("Content-Type") != _contentType {
+			t.Errorf("Server(%v) Content-Type got: %v, want: %v", tt.name, resp.Header.Get("Content-Type"), _contentType)
+		}
+	}
+}
+
+func TestServer_List(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	var ids []string
+	for i := 0; i < 5; i++ {
+		id := uuid.NewString()
+		resp, err := http.Post(srv.URL+_accountsPath, "application/json", strings.NewReader(strings.Replace(_accountBodyStub, _idStub, id, 1)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		ids = append(ids, id)
+	}
+
+	cases := []struct {
+		name     string
+		query    url.Values
+		wantIDs  []string
+		wantNext string
+	}{
+		{"default page size", url.Values{}, ids, ""},
+		{"first page", url.Values{_pageSizeParam: {"2"}}, ids[:2], "1"},
+		{"last page", url.Values{_pageSizeParam: {"2"}, _pageNumberParam: {"2"}}, ids[4:], ""},
+		{"beyond last page", url.Values{_pageSizeParam: {"2"}, _pageNumberParam: {"3"}}, nil, ""},
+	}
+
+	for _, tt := range cases {
+		resp, err := http.Get(srv.URL + _accountsPath + "?" + tt.query.Encode())
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got listPayload
+		_ = json.NewDecoder(resp.Body).Decode(&got)
+		resp.Body.Close()
+
+		var gotIDs []string
+		for _, acc := range got.Data {
+			gotIDs = append(gotIDs, acc.ID)
+		}
+		var gotNext string
+		if next, err := url.Parse(got.Links.Next); got.Links.Next != "" && err == nil {
+			gotNext = next.Query().Get(_pageNumberParam)
+		}
+		if strings.Join(gotIDs, ",") != strings.Join(tt.wantIDs, ",") || gotNext != tt.wantNext {
+			t.Errorf("Server_List(%v) got: %v next %q, want: %v next %q", tt.name, gotIDs, gotNext, tt.wantIDs, tt.wantNext)
+		}
+	}
+}
+
+func TestServer_Service(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	baseURL, _ := url.Parse(srv.URL)
+	svc := acc.NewService(acc.NewHTTPRepository(acc.WithBaseURL(baseURL)))
+	cr := acc.CreateRequest{ID: _idStub, OrganisationID: _organisationIDStub, Country: "GB", Name: []string{"BRUCE", "WAYNE"}}
+
+	created, err := svc.Create(cr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	updated, err := svc.Update(created.ID(), created.Version(), acc.UpdateRequest{Name: []string{"Jane Doe"}})
+	if err != nil || updated.Version() != 1 || updated.Name()[0] != "Jane Doe" {
+		t.Errorf("Server_Service(update) got: %+v, %v, want: version 1, name Jane Doe", updated, err)
+	}
+	if _, err := svc.Create(cr); !errors.Is(err, acc.ErrConflict) {
+		t.Errorf("Server_Service(create duplicate) got: %v, want: %v", err, acc.ErrConflict)
+	}
+	if err := svc.Delete(created); !errors.Is(err, acc.ErrVersionConflict) {
+		t.Errorf("Server_Service(delete stale version) got: %v, want: %v", err, acc.ErrVersionConflict)
+	}
+	if err := svc.Delete(updated); err != nil {
+		t.Errorf("Server_Service(delete) got: %v, want: nil", err)
+	}
+	if _, err := svc.Fetch(_idStub); !errors.Is(err, acc.ErrNotFound) {
+		t.Errorf("Server_Service(fetch deleted) got: %v, want: %v", err, acc.ErrNotFound)
+	}
+}
@@ -0,0 +1,338 @@
+package account
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+type (
+	// CassetteMode tells whether a Cassette records or replays the exchanges with account-api.
+	CassetteMode int
+	// Cassette records the exchanges with account-api to a JSON fixture file, or replays them, so tests can run
+	// deterministically against exchanges captured once, e.g. against a staging account-api. It is given to the HTTP
+	// repository with WithCassette and is safe for concurrent use.
+	//
+	// Recorded exchanges are scrubbed: the values of the redacted JSON attributes and list filters are masked, as by
+	// WithBodyLogging, along with access tokens and the Authorization, Proxy-Authorization, Cookie, Set-Cookie and
+	// Signature headers.
+	//
+	// A replayed request matches a recorded one with the same method, path, query and body, bodies being compared
+	// once scrubbed and normalized. Hosts and headers are ignored. Each recorded exchange is replayed once, in the
+	// order it was recorded. A request matching none fails with an error matching ErrCassetteMiss.
+	//
+	// Example, recording once with -record and replaying afterwards:
+	//
+	//	mode := acc.CassetteReplay
+	//	if *record {
+	//		mode = acc.CassetteRecord
+	//	}
+	//	cassette, err := acc.NewCassette("testdata/fetch.json", mode)
+	//	if err != nil {
+	//		t.Fatal(err)
+	//	}
+	//	t.Cleanup(func() { _ = cassette.Save() })
+	//	repository := acc.NewHTTPRepository(acc.WithBaseURL(staging), acc.WithCassette(cassette))
+	Cassette struct {
+		mode   CassetteMode
+		path   string
+		redact map[string]bool
+		errCtx string
+
+		mu           sync.Mutex
+		interactions []interaction
+		replayed     []bool
+	}
+	cassetteFile struct {
+		Interactions []interaction `json:"interactions"`
+	}
+	interaction struct {
+		Request  recordedRequest  `json:"request"`
+		Response recordedResponse `json:"response"`
+	}
+	recordedRequest struct {
+		Method string      `json:"method"`
+		Path   string      `json:"path"`
+		Query  string      `json:"query,omitempty"`
+		Header http.Header `json:"header,omitempty"`
+		recordedBody
+	}
+	recordedResponse struct {
+		StatusCode int         `json:"status_code"`
+		Header     http.Header `json:"header,omitempty"`
+		recordedBody
+	}
+	// recordedBody holds a JSON body in Body, or any other body in Text.
+	recordedBody struct {
+		Body json.RawMessage `json:"body,omitempty"`
+		Text string          `json:"text,omitempty"`
+	}
+	cassetteRequester struct {
+		Requester
+		cassette *Cassette
+	}
+	cassetteOption struct{ *Cassette }
+)
+
+const (
+	// CassetteReplay answers requests with the exchanges of the fixture file, without sending them.
+	CassetteReplay CassetteMode = iota
+	// CassetteRecord sends requests and records the exchanges, written to the fixture file by Cassette.Save.
+	CassetteRecord
+)
+
+var (
+	_scrubbedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "Signature"}
+	_scrubbedSecrets = []string{"access_token", "refresh_token"}
+)
+
+// NewCassette instantiates a Cassette for the fixture file at path. In CassetteReplay mode the file is loaded and
+// must exist. In CassetteRecord mode the exchanges recorded replace the content of the file when it is saved.
+//
+// redact holds the names of the JSON attributes and list filters to be scrubbed. Default is the one of LogOptions.
+func NewCassette(path string, mode CassetteMode, redact ...string) (*Cassette, error) {
+	c := &Cassette{mode: mode, path: path, redact: map[string]bool{}, errCtx: "cassette"}
+	if redact == nil {
+		redact = _defaultRedact
+	}
+	for _, fields := range [][]string{redact, _scrubbedSecrets} {
+		for _, f := range fields {
+			c.redact[f] = true
+		}
+	}
+	if mode == CassetteRecord {
+		return c, nil
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s#NewCassette() read", c.errCtx)
+	}
+	var file cassetteFile
+	if err := json.Unmarshal(raw, &file); err != nil {
+		return nil, errors.Wrapf(err, "%s#NewCassette() decode: %s", c.errCtx, path)
+	}
+	for i := range file.Interactions {
+		req := &file.Interactions[i].Request
+		if req.Body, err = c.normalize(req.Body); err != nil {
+			return nil, errors.Wrapf(err, "%s#NewCassette() normalize: %s %s", c.errCtx, req.Method, req.Path)
+		}
+	}
+	c.interactions = file.Interactions
+	c.replayed = make([]bool, len(file.Interactions))
+
+	return c, nil
+}
+
+// WithCassette makes HTTP client record or replay the exchanges with account-api with c. It is the innermost
+// middleware, so the requests it sees are signed and authorized, and in CassetteReplay mode nothing is sent.
+func WithCassette(c *Cassette) httpOption {
+	return cassetteOption{c}
+}
+
+// Save writes the exchanges recorded to the fixture file, creating its directory when needed. It does nothing in
+// CassetteReplay mode.
+func (c *Cassette) Save() error {
+	if c.mode != CassetteRecord {
+		return nil
+	}
+
+	c.mu.Lock()
+	file := cassetteFile{Interactions: c.interactions}
+	raw, err := json.MarshalIndent(file, "", "  ")
+	c.mu.Unlock()
+	if err != nil {
+		return errors.Wrapf(err, "%s#Save() encode", c.errCtx)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return errors.Wrapf(err, "%s#Save() mkdir", c.errCtx)
+	}
+	if err := os.WriteFile(c.path, append(raw, '\n'), 0o644); err != nil {
+		return errors.Wrapf(err, "%s#Save() write", c.errCtx)
+	}
+
+	return nil
+}
+
+func (c *Cassette) middleware(next Requester) Requester {
+	return cassetteRequester{Requester: next, cassette: c}
+}
+
+func (r cassetteRequester) Do(req *http.Request) (*http.Response, error) {
+	c := r.cassette
+
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		raw, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, errors.Wrapf(err, "%s#Do() read request body", c.errCtx)
+		}
+		body = raw
+	}
+	recorded := recordedRequest{
+		Method:       req.Method,
+		Path:         req.URL.Path,
+		Query:        redactQuery(req.URL.Query(), c.redact).Encode(),
+		Header:       c.scrubHeader(req.Header),
+		recordedBody: c.scrubBody(body),
+	}
+
+	if c.mode == CassetteReplay {
+		return c.replay(req, recorded)
+	}
+
+	sent := req.Clone(req.Context())
+	if body != nil {
+		sent.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	resp, err := r.Requester.Do(sent)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s#Do() read response body", c.errCtx)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(raw))
+
+	// The body is recorded scrubbed, so its length is not the one received.
+	header := c.scrubHeader(resp.Header)
+	header.Del("Content-Length")
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.interactions = append(c.interactions, interaction{
+		Request: recorded,
+		Response: recordedResponse{
+			StatusCode:   resp.StatusCode,
+			Header:       header,
+			recordedBody: c.scrubBody(raw),
+		},
+	})
+
+	return resp, nil
+}
+
+// replay returns the response of the first exchange matching recorded that has not been replayed yet.
+func (c *Cassette) replay(req *http.Request, recorded recordedRequest) (*http.Response, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i, in := range c.interactions {
+		if c.replayed[i] || !in.Request.matches(recorded) {
+			continue
+		}
+		c.replayed[i] = true
+
+		body := []byte(in.Response.Text)
+		if in.Response.Body != nil {
+			body = in.Response.Body
+		}
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", in.Response.StatusCode, http.StatusText(in.Response.StatusCode)),
+			StatusCode:    in.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        in.Response.Header.Clone(),
+			Body:          io.NopCloser(bytes.NewReader(body)),
+			ContentLength: int64(len(body)),
+			Request:       req,
+		}, nil
+	}
+
+	return nil, errors.Wrapf(ErrCassetteMiss, "%s#replay() %s %s?%s %s%s: %s", c.errCtx,
+		recorded.Method, recorded.Path, recorded.Query, recorded.Body, recorded.Text, c.path)
+}
+
+func (r recordedRequest) matches(o recordedRequest) bool {
+	return r.Method == o.Method && r.Path == o.Path && r.Query == o.Query &&
+		bytes.Equal(r.Body, o.Body) && r.Text == o.Text
+}
+
+// scrubHeader returns a copy of h with the values of the authentication headers masked.
+func (c *Cassette) scrubHeader(h http.Header) http.Header {
+	scrubbed := h.Clone()
+	for _, k := range _scrubbedHeaders {
+		if scrubbed.Get(k) != "" {
+			scrubbed.Set(k, _redacted)
+		}
+	}
+	if len(scrubbed) == 0 {
+		return nil
+	}
+
+	return scrubbed
+}
+
+// scrubBody returns raw in Body, normalized and with the values of the redacted attributes masked, when it is a JSON
+// document, and in Text otherwise.
+func (c *Cassette) scrubBody(raw []byte) recordedBody {
+	if len(raw) == 0 {
+		return recordedBody{}
+	}
+	body, err := c.normalize(raw)
+	if err != nil {
+		return recordedBody{Text: string(raw)}
+	}
+
+	return recordedBody{Body: body}
+}
+
+// normalize decodes the JSON document raw and encodes it back, with object keys sorted, no spaces and the values of
+// the redacted attributes masked.
+func (c *Cassette) normalize(raw json.RawMessage) (json.RawMessage, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(c.scrubValue(doc, false))
+}
+
+// scrubValue masks the strings of v, at any depth, that are the values of redacted attributes, or all of them when
+// scrub is true, and the redacted list filters of the URLs, e.g. in links. The shape of v is kept, so scrubbed
+// documents still decode into the same types.
+func (c *Cassette) scrubValue(v interface{}, scrub bool) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, e := range t {
+			t[k] = c.scrubValue(e, scrub || c.redact[k])
+		}
+	case []interface{}:
+		for i, e := range t {
+			t[i] = c.scrubValue(e, scrub)
+		}
+	case string:
+		if scrub {
+			return _redacted
+		}
+		if u, err := url.Parse(t); err == nil && strings.Contains(u.RawQuery, "filter") {
+			return redactURL(u, c.redact)
+		}
+	}
+
+	return v
+}
+
+func (c cassetteOption) apply(opts *httpOptions) {
+	opts.cassette = c.Cassette
+}
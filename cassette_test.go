@@ -0,0 +1,167 @@
+package account
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/r1cm3d/account-lib/accounttest"
+)
+
+const _cassetteFixture = `{
+  "interactions": [
+    {
+      "request": {"method": "POST", "path": "/v1/organisation/accounts", "body": {"data": {"version": 0, "id": "ad27e265-9605-4b4b-a0e5-3003ea9cc4d2"}}},
+      "response": {"status_code": 201, "header": {"X-Request-Id": ["first"]}, "body": {"data": {"id": "ad27e265-9605-4b4b-a0e5-3003ea9cc4d2"}}}
+    },
+    {
+      "request": {"method": "POST", "path": "/v1/organisation/accounts", "body": {"data": {"id": "ad27e265-9605-4b4b-a0e5-3003ea9cc4d2", "version": 0}}},
+      "response": {"status_code": 409, "text": "conflict"}
+    },
+    {
+      "request": {"method": "GET", "path": "/v1/organisation/accounts", "query": "filter%5Biban%5D=%5BREDACTED%5D"},
+      "response": {"status_code": 200, "body": {"data": []}}
+    }
+  ]
+}`
+
+func TestCassette(t *testing.T) {
+	srv := accounttest.NewServer()
+	defer srv.Close()
+	baseURL, _ := url.Parse(srv.URL)
+	path := filepath.Join(t.TempDir(), "testdata", "cassette.json")
+	const token = "Bearer secret-token"
+	auth := func(next Requester) Requester {
+		return RequesterFunc(func(req *http.Request) (*http.Response, error) {
+			req = req.Clone(req.Context())
+			req.Header.Set("Authorization", token)
+			return next.Do(req)
+		})
+	}
+
+	recorder, err := NewCassette(path, CassetteRecord)
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc := NewService(NewHTTPRepository(WithBaseURL(baseURL), WithMiddleware(auth), WithCassette(recorder)))
+	created, err := svc.Create(_basicFilledCreateRequest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.List(ListOptions{Filter: ListFilter{Iban: _ibanStub}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.Fetch(_idStub); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Cassette(record not found) got: %v, want: %v", err, ErrNotFound)
+	}
+	if err := recorder.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{token, _ibanStub, _nameStub[0]} {
+		if strings.Contains(string(raw), secret) {
+			t.Errorf("Cassette(scrubbed) got: %s in fixture, want: %s", secret, _redacted)
+		}
+	}
+
+	replayer, err := NewCassette(path, CassetteReplay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unreachable, _ := url.Parse("http://127.0.0.1:1")
+	svc = NewService(NewHTTPRepository(WithBaseURL(unreachable), WithCassette(replayer)))
+
+	got, err := svc.Create(_basicFilledCreateRequest)
+	if err != nil || got.ID() != created.ID() || got.Iban() != _redacted || got.Name()[0] != _redacted {
+		t.Errorf("Cassette(replay create) got: %+v, %v, want: %v with scrubbed PII", got, err, created.ID())
+	}
+	if page, err := svc.List(ListOptions{Filter: ListFilter{Iban: "GB29NWBK60161331926819"}}); err != nil || len(page.Accounts) != 1 {
+		t.Errorf("Cassette(replay list) got: %+v, %v, want: 1 account", page, err)
+	}
+	if _, err := svc.Fetch(_idStub); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Cassette(replay not found) got: %v, want: %v", err, ErrNotFound)
+	}
+	if _, err := svc.Fetch(_idStub); !errors.Is(err, ErrCassetteMiss) {
+		t.Errorf("Cassette(replayed once) got: %v, want: %v", err, ErrCassetteMiss)
+	}
+}
+
+func TestCassette_Replay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	if err := os.WriteFile(path, []byte(_cassetteFixture), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c, err := NewCassette(path, CassetteReplay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replayer := c.middleware(nil)
+	const createBody = `{"data":{"id":"ad27e265-9605-4b4b-a0e5-3003ea9cc4d2","version":0}}`
+
+	cases := []struct {
+		name       string
+		method     string
+		url        string
+		body       string
+		wantStatus int
+		wantBody   string
+		wantErr    error
+	}{
+		{"normalized body", _post, "https://staging.example.com/v1/organisation/accounts", createBody, 201, `{"data": {"id": "ad27e265-9605-4b4b-a0e5-3003ea9cc4d2"}}`, nil},
+		{"in order", _post, "http://localhost/v1/organisation/accounts", createBody, 409, "conflict", nil},
+		{"replayed once", _post, "http://localhost/v1/organisation/accounts", createBody, 0, "", ErrCassetteMiss},
+		{"scrubbed query", _get, "http://localhost/v1/organisation/accounts?filter%5Biban%5D=GB33BUKB20201555555555", "", 200, `{"data": []}`, nil},
+		{"unmatched query", _get, "http://localhost/v1/organisation/accounts?page%5Bsize%5D=1", "", 0, "", ErrCassetteMiss},
+		{"unmatched method", _delete, "http://localhost/v1/organisation/accounts", "", 0, "", ErrCassetteMiss},
+	}
+
+	for _, tt := range cases {
+		req, _ := http.NewRequest(tt.method, tt.url, strings.NewReader(tt.body))
+		resp, err := replayer.Do(req)
+		if tt.wantErr != nil {
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Cassette_Replay(%v) got: %v, want: %v", tt.name, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Cassette_Replay(%v) got: %v, want: nil", tt.name, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != tt.wantStatus || string(body) != tt.wantBody {
+			t.Errorf("Cassette_Replay(%v) got: %d %s, want: %d %s", tt.name, resp.StatusCode, body, tt.wantStatus, tt.wantBody)
+		}
+	}
+}
+
+func TestNewCassette_Error(t *testing.T) {
+	dir := t.TempDir()
+	malformed := filepath.Join(dir, "malformed.json")
+	if err := os.WriteFile(malformed, []byte(`{"interactions":`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name string
+		path string
+		want string
+	}{
+		{"missing", filepath.Join(dir, "missing.json"), "cassette#NewCassette() read: "},
+		{"malformed", malformed, "cassette#NewCassette() decode: "},
+	}
+
+	for _, tt := range cases {
+		if _, err := NewCassette(tt.path, CassetteReplay); err == nil || !strings.HasPrefix(err.Error(), tt.want) {
+			t.Errorf("NewCassette_Error(%v) got: %v, want: %v", tt.name, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package account
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+type (
+	// CircuitState is the state of the circuit breaker.
+	CircuitState int
+	// CircuitBreaker configures when account-api calls stop being sent. While closed, calls go through and their
+	// outcomes are counted. When the ratio of failed calls reaches FailureRatio the circuit opens, and calls fail
+	// with CircuitOpenError without reaching account-api. After CoolDown the circuit goes half-open: the next call
+	// probes account-api health and, if it is healthy, the circuit closes and the call goes through. Otherwise the
+	// circuit opens again.
+	//
+	// A call fails when no response is received, e.g. connection refused or timeout, or when the response status code
+	// is 5xx. Calls given up because their context is done are not counted.
+	CircuitBreaker struct {
+		// FailureRatio is the ratio, between 0 and 1, of failed calls that opens the circuit. Default is 0.5.
+		FailureRatio float64
+		// MinRequests is the number of calls in Window below which the circuit never opens, so a handful of failures
+		// do not open it. Default is 10.
+		MinRequests int
+		// Window is the period over which calls are counted. Counts are reset at the end of each one. Default is 10s.
+		Window time.Duration
+		// CoolDown is how long the circuit stays open before account-api is probed. Default is 30s.
+		CoolDown time.Duration
+		// OnStateChange, if set, is called at every state change, e.g. to raise an alert. It is called
+		// synchronously by the call that triggered the change, so it should return quickly. The change to half-open is
+		// notified along with the outcome of the probe, once it is over. A probe given up because the context of its
+		// call is done is not notified, as nothing is learnt about account-api.
+		OnStateChange func(from, to CircuitState)
+	}
+	circuitBreaker struct {
+		Requester
+		config CircuitBreaker
+		probe  func(ctx context.Context) error
+		now    func() time.Time
+
+		mu          sync.Mutex
+		state       CircuitState
+		openedAt    time.Time
+		windowStart time.Time
+		requests    int
+		failures    int
+	}
+)
+
+const (
+	// CircuitClosed lets calls through.
+	CircuitClosed CircuitState = iota
+	// CircuitOpen fails calls fast.
+	CircuitOpen
+	// CircuitHalfOpen probes account-api before letting calls through again.
+	CircuitHalfOpen
+)
+
+const (
+	_defaultFailureRatio = 0.5
+	_defaultMinRequests  = 10
+	_defaultWindow       = 10 * time.Second
+	_defaultCoolDown     = 30 * time.Second
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+func newCircuitBreaker(next Requester, config CircuitBreaker, probe func(ctx context.Context) error) *circuitBreaker {
+	if config.FailureRatio <= 0 {
+		config.FailureRatio = _defaultFailureRatio
+	}
+	if config.MinRequests <= 0 {
+		config.MinRequests = _defaultMinRequests
+	}
+	if config.Window <= 0 {
+		config.Window = _defaultWindow
+	}
+	if config.CoolDown <= 0 {
+		config.CoolDown = _defaultCoolDown
+	}
+
+	return &circuitBreaker{
+		Requester: next,
+		config:    config,
+		probe:     probe,
+		now:       time.Now,
+	}
+}
+
+// circuitBreakerMiddleware opens the circuit according to config, probing the health of account-api at baseURL
+// through the next Requester.
+func circuitBreakerMiddleware(config CircuitBreaker, baseURL url.URL) Middleware {
+	return func(next Requester) Requester {
+		probe := httpRepository{
+			baseURL: baseURL,
+			errCtx:  "circuit_breaker",
+			client:  httpclient{buildRequest: http.NewRequestWithContext, Requester: next, errCtx: "http_client"},
+		}
+		return newCircuitBreaker(next, config, func(ctx context.Context) error {
+			_, err := probe.Health(ctx)
+			return err
+		})
+	}
+}
+
+func (b *circuitBreaker) Do(req *http.Request) (*http.Response, error) {
+	if err := b.allow(req.Context()); err != nil {
+		return nil, err
+	}
+
+	resp, err := b.Requester.Do(req)
+	if req.Context().Err() == nil {
+		b.record(err != nil || resp.StatusCode >= http.StatusInternalServerError)
+	}
+
+	return resp, err
+}
+
+// allow returns nil when a call can go through, probing account-api when the cool-down is over.
+func (b *circuitBreaker) allow(ctx context.Context) error {
+	b.mu.Lock()
+	now := b.now()
+	switch b.state {
+	case CircuitClosed:
+		b.mu.Unlock()
+		return nil
+	case CircuitOpen:
+		if retryAt := b.openedAt.Add(b.config.CoolDown); now.Before(retryAt) {
+			b.mu.Unlock()
+			return &CircuitOpenError{RetryAt: retryAt}
+		}
+	default:
+		// Another call is probing account-api.
+		b.mu.Unlock()
+		return &CircuitOpenError{RetryAt: now}
+	}
+	notifyHalfOpen := b.transition(CircuitHalfOpen)
+	b.mu.Unlock()
+
+	err := b.probe(ctx)
+
+	b.mu.Lock()
+	var notify func()
+	switch {
+	case err == nil:
+		notify = b.transition(CircuitClosed)
+	case ctx.Err() != nil:
+		// The probe was given up, so the circuit opens again silently, without restarting the cool-down, and the next
+		// call probes again.
+		b.transition(CircuitOpen)
+		b.mu.Unlock()
+		return ctx.Err()
+	default:
+		b.openedAt = b.now()
+		err = &CircuitOpenError{RetryAt: b.openedAt.Add(b.config.CoolDown)}
+		notify = b.transition(CircuitOpen)
+	}
+	b.mu.Unlock()
+	notifyHalfOpen()
+	notify()
+
+	return err
+}
+
+// record counts the outcome of a call, opening the circuit when too many calls failed.
+func (b *circuitBreaker) record(failed bool) {
+	b.mu.Lock()
+	if b.state != CircuitClosed {
+		b.mu.Unlock()
+		return
+	}
+
+	now := b.now()
+	if now.Sub(b.windowStart) >= b.config.Window {
+		b.windowStart = now
+		b.requests, b.failures = 0, 0
+	}
+	b.requests++
+	if failed {
+		b.failures++
+	}
+
+	notify := func() {}
+	if b.requests >= b.config.MinRequests && float64(b.failures) >= b.config.FailureRatio*float64(b.requests) {
+		b.openedAt = now
+		notify = b.transition(CircuitOpen)
+	}
+	b.mu.Unlock()
+	notify()
+}
+
+// transition changes the state, resetting the counts, and returns the function notifying OnStateChange. It must be
+// called with mu held, while the returned function must be called without it.
+func (b *circuitBreaker) transition(to CircuitState) func() {
+	from := b.state
+	b.state = to
+	b.windowStart = b.now()
+	b.requests, b.failures = 0, 0
+
+	if from == to || b.config.OnStateChange == nil {
+		return func() {}
+	}
+
+	return func() { b.config.OnStateChange(from, to) }
+}
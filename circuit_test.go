@@ -0,0 +1,191 @@
+package account
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Date(2021, 7, 1, 12, 0, 0, 0, time.UTC)
+	var changes []string
+	next := &mockScriptedRequester{responses: []mockResponse{{status: 200}, {status: 503}, {status: 503}, {status: 200}}}
+	probes := 0
+	b := newCircuitBreaker(next, CircuitBreaker{MinRequests: 4, CoolDown: time.Minute, OnStateChange: func(from, to CircuitState) {
+		changes = append(changes, fmt.Sprintf("%v->%v", from, to))
+	}}, func(context.Context) error { probes++; return nil })
+	b.now = func() time.Time { return now }
+	req, _ := http.NewRequest(_get, "http://localhost", nil)
+
+	for i := 0; i < 4; i++ {
+		if _, err := b.Do(req); err != nil {
+			t.Fatalf("CircuitBreaker closed got: %v, want: nil", err)
+		}
+	}
+	_, err := b.Do(req)
+	var openErr *CircuitOpenError
+	if !errors.As(err, &openErr) || !errors.Is(err, ErrCircuitOpen) || !openErr.RetryAt.Equal(now.Add(time.Minute)) {
+		t.Errorf("CircuitBreaker open got: %v, want: %v", err, &CircuitOpenError{RetryAt: now.Add(time.Minute)})
+	}
+	if len(next.methods) != 4 {
+		t.Errorf("CircuitBreaker open requests got: %d, want: 4", len(next.methods))
+	}
+
+	now = now.Add(time.Minute)
+	if _, err := b.Do(req); err != nil || probes != 1 || len(next.methods) != 5 {
+		t.Errorf("CircuitBreaker probe got: %v, %d probes, %d requests, want: nil, 1 probe, 5 requests", err, probes, len(next.methods))
+	}
+
+	want := []string{"closed->open", "open->half-open", "half-open->closed"}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("CircuitBreaker state changes got: %v, want: %v", changes, want)
+	}
+}
+
+func TestCircuitBreaker_ProbeError(t *testing.T) {
+	now := time.Date(2021, 7, 1, 12, 0, 0, 0, time.UTC)
+	var changes []string
+	next := &mockScriptedRequester{responses: []mockResponse{{err: errors.New("connection refused")}}}
+	b := newCircuitBreaker(next, CircuitBreaker{MinRequests: 1, CoolDown: time.Minute, OnStateChange: func(from, to CircuitState) {
+		changes = append(changes, fmt.Sprintf("%v->%v", from, to))
+	}}, func(context.Context) error { return errors.New("unhealthy") })
+	b.now = func() time.Time { return now }
+	req, _ := http.NewRequest(_get, "http://localhost", nil)
+
+	_, _ = b.Do(req)
+	now = now.Add(time.Minute)
+	_, err := b.Do(req)
+
+	var openErr *CircuitOpenError
+	if !errors.As(err, &openErr) || !openErr.RetryAt.Equal(now.Add(time.Minute)) {
+		t.Errorf("CircuitBreaker_ProbeError got: %v, want: %v", err, &CircuitOpenError{RetryAt: now.Add(time.Minute)})
+	}
+	want := []string{"closed->open", "open->half-open", "half-open->open"}
+	if !reflect.DeepEqual(changes, want) || len(next.methods) != 1 {
+		t.Errorf("CircuitBreaker_ProbeError got: %v, %d requests, want: %v, 1 request", changes, len(next.methods), want)
+	}
+}
+
+func TestCircuitBreaker_Ratio(t *testing.T) {
+	cases := []struct {
+		name      string
+		responses []mockResponse
+		advance   time.Duration
+		want      CircuitState
+	}{
+		{"below ratio", []mockResponse{{status: 200}, {status: 200}, {status: 500}, {status: 200}}, 0, CircuitClosed},
+		{"client errors", []mockResponse{{status: 400}, {status: 404}, {status: 409}, {status: 429}}, 0, CircuitClosed},
+		{"at ratio", []mockResponse{{status: 200}, {status: 500}, {status: 200}, {status: 502}}, 0, CircuitOpen},
+		{"window reset", []mockResponse{{status: 500}, {status: 500}, {status: 500}, {status: 200}}, 4 * time.Second, CircuitClosed},
+	}
+
+	for _, tt := range cases {
+		now := time.Date(2021, 7, 1, 12, 0, 0, 0, time.UTC)
+		next := &mockScriptedRequester{responses: tt.responses}
+		b := newCircuitBreaker(next, CircuitBreaker{MinRequests: 4, Window: 10 * time.Second}, nil)
+		b.now = func() time.Time { return now }
+		req, _ := http.NewRequest(_get, "http://localhost", nil)
+
+		for range tt.responses {
+			_, _ = b.Do(req)
+			now = now.Add(tt.advance)
+		}
+
+		if b.state != tt.want {
+			t.Errorf("CircuitBreaker_Ratio(%v) got: %v, want: %v", tt.name, b.state, tt.want)
+		}
+	}
+}
+
+func TestCircuitBreaker_Context(t *testing.T) {
+	next := &mockScriptedRequester{responses: []mockResponse{{err: context.Canceled}}}
+	b := newCircuitBreaker(next, CircuitBreaker{MinRequests: 1}, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req, _ := http.NewRequestWithContext(ctx, _get, "http://localhost", nil)
+
+	_, _ = b.Do(req)
+
+	if b.state != CircuitClosed {
+		t.Errorf("CircuitBreaker_Context got: %v, want: %v", b.state, CircuitClosed)
+	}
+}
+
+func TestCircuitBreaker_ProbeContext(t *testing.T) {
+	now := time.Date(2021, 7, 1, 12, 0, 0, 0, time.UTC)
+	var changes []string
+	next := &mockScriptedRequester{responses: []mockResponse{{err: errors.New("connection refused")}, {status: 200}}}
+	ctx, cancel := context.WithCancel(context.Background())
+	probes := 0
+	b := newCircuitBreaker(next, CircuitBreaker{MinRequests: 1, CoolDown: time.Minute, OnStateChange: func(from, to CircuitState) {
+		changes = append(changes, fmt.Sprintf("%v->%v", from, to))
+	}}, func(probeCtx context.Context) error {
+		probes++
+		cancel()
+		return probeCtx.Err()
+	})
+	b.now = func() time.Time { return now }
+	req, _ := http.NewRequest(_get, "http://localhost", nil)
+
+	_, _ = b.Do(req)
+	now = now.Add(time.Minute)
+	if _, err := b.Do(req.WithContext(ctx)); !errors.Is(err, context.Canceled) {
+		t.Errorf("CircuitBreaker_ProbeContext got: %v, want: %v", err, context.Canceled)
+	}
+	want := []string{"closed->open"}
+	if !reflect.DeepEqual(changes, want) || b.state != CircuitOpen {
+		t.Errorf("CircuitBreaker_ProbeContext got: %v, %v, want: %v, %v", changes, b.state, want, CircuitOpen)
+	}
+
+	b.probe = func(context.Context) error { probes++; return nil }
+	if _, err := b.Do(req); err != nil || probes != 2 {
+		t.Errorf("CircuitBreaker_ProbeContext next probe got: %v, %d probes, want: nil, 2 probes", err, probes)
+	}
+	want = []string{"closed->open", "open->half-open", "half-open->closed"}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("CircuitBreaker_ProbeContext state changes got: %v, want: %v", changes, want)
+	}
+}
+
+func TestWithCircuitBreaker(t *testing.T) {
+	var accounts, health int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/health" {
+			atomic.AddInt32(&health, 1)
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		atomic.AddInt32(&accounts, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+	baseURL, _ := url.Parse(srv.URL)
+	repo := NewHTTPRepository(
+		WithBaseURL(baseURL),
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 5, BaseDelay: time.Millisecond}),
+		WithCircuitBreaker(CircuitBreaker{MinRequests: 2, CoolDown: 50 * time.Millisecond}),
+	)
+
+	if _, err := repo.Fetch(context.Background(), _fakeStubID); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("WithCircuitBreaker got: %v, want: %v", err, ErrCircuitOpen)
+	}
+	if got := atomic.LoadInt32(&accounts); got != 2 {
+		t.Errorf("WithCircuitBreaker requests got: %d, want: 2", got)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	if _, err := repo.Fetch(context.Background(), _fakeStubID); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("WithCircuitBreaker after cool-down got: %v, want: %v", err, ErrCircuitOpen)
+	}
+	if got, probes := atomic.LoadInt32(&accounts), atomic.LoadInt32(&health); got != 4 || probes != 1 {
+		t.Errorf("WithCircuitBreaker after cool-down got: %d requests, %d probes, want: 4 requests, 1 probe", got, probes)
+	}
+}
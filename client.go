@@ -1,49 +1,156 @@
 package account
 
 import (
-	"github.com/pkg/errors"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"io"
 	"net/http"
+	"os"
+
+	"github.com/pkg/errors"
 )
 
 type (
 	method       string
-	buildRequest func(method, url string, body io.Reader) (*http.Request, error)
-	requester    interface {
+	buildRequest func(ctx context.Context, method, url string, body io.Reader) (*http.Request, error)
+	// Requester sends an HTTP request to account-api and returns its response. *http.Client implements it.
+	Requester interface {
 		Do(req *http.Request) (*http.Response, error)
 	}
 	httpclient struct {
 		buildRequest
-		requester
+		Requester
 
 		errCtx string
 	}
+	// errRequester fails every request with err. It is used when the HTTP client or the base URL could not be
+	// configured.
+	errRequester struct {
+		err error
+	}
 )
 
 const (
 	_post   = "POST"
 	_delete = "DELETE"
 	_get    = "GET"
+	_patch  = "PATCH"
 )
 
-func newHTTPClient() httpclient {
+func newHTTPClient(opts httpOptions) httpclient {
+	const errCtx = "http_client"
+
+	var req Requester
+	c, err := newStdClient(opts)
+	switch {
+	case opts.baseURLErr != nil:
+		req = errRequester{errors.Wrapf(opts.baseURLErr, "%s#newHTTPClient() base URL", errCtx)}
+	case err != nil:
+		req = errRequester{errors.Wrapf(err, "%s#newHTTPClient()", errCtx)}
+	default:
+		req = c
+	}
+
 	return httpclient{
-		buildRequest: http.NewRequest,
-		requester:    &http.Client{},
-		errCtx:       "http_client",
+		buildRequest: http.NewRequestWithContext,
+		Requester:    chain(req, opts.middlewares()...),
+		errCtx:       errCtx,
 	}
 }
 
-func (h httpclient) request(method method, url string, body io.Reader) (*http.Response, error) {
-	req, err := h.buildRequest(string(method), url, body)
+func newStdClient(opts httpOptions) (*http.Client, error) {
+	c := &http.Client{}
+	if opts.httpClient != nil {
+		cp := *opts.httpClient
+		c = &cp
+	}
+	if opts.timeout > 0 {
+		c.Timeout = opts.timeout
+	}
+
+	tlsConfig, err := newTLSConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig == nil {
+		return c, nil
+	}
+
+	var transport *http.Transport
+	switch t := c.Transport.(type) {
+	case nil:
+		transport = http.DefaultTransport.(*http.Transport).Clone()
+	case *http.Transport:
+		transport = t.Clone()
+	default:
+		return nil, errors.Errorf("tls: transport %T is not a *http.Transport", t)
+	}
+	transport.TLSClientConfig = tlsConfig
+	c.Transport = transport
+
+	return c, nil
+}
+
+func newTLSConfig(opts httpOptions) (*tls.Config, error) {
+	if opts.tlsConfig == nil && opts.clientCert == nil {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if opts.tlsConfig != nil {
+		tlsConfig = opts.tlsConfig.Clone()
+	}
+	if opts.clientCert == nil {
+		return tlsConfig, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(opts.clientCert.cert, opts.clientCert.key)
+	if err != nil {
+		return nil, errors.Wrap(err, "tls: load client certificate")
+	}
+	tlsConfig.Certificates = append(tlsConfig.Certificates, cert)
+
+	if opts.clientCert.ca == "" {
+		return tlsConfig, nil
+	}
+	caPEM, err := os.ReadFile(opts.clientCert.ca)
+	if err != nil {
+		return nil, errors.Wrap(err, "tls: read CA")
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPEM) {
+		return nil, errors.Errorf("tls: no certificate found in CA %s", opts.clientCert.ca)
+	}
+	tlsConfig.RootCAs = pool
+
+	return tlsConfig, nil
+}
+
+// request builds and sends an HTTP request bound to ctx. When the request fails because ctx was cancelled or its
+// deadline expired, the returned error wraps ctx.Err(), so callers can check it with errors.Is against
+// context.Canceled or context.DeadlineExceeded.
+func (h httpclient) request(ctx context.Context, method method, url string, body io.Reader) (*http.Response, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, errors.Wrapf(err, "%s#request() context", h.errCtx)
+	}
+
+	req, err := h.buildRequest(ctx, string(method), url, body)
 	if err != nil {
 		return nil, errors.Wrapf(err, "%s#request() buildRequest", h.errCtx)
 	}
 
 	resp, err := h.Do(req)
 	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, errors.Wrapf(ctxErr, "%s#request() context", h.errCtx)
+		}
 		return nil, errors.Wrapf(err, "%s#request() do", h.errCtx)
 	}
 
 	return resp, nil
 }
+
+func (e errRequester) Do(_ *http.Request) (*http.Response, error) {
+	return nil, e.err
+}
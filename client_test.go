@@ -1,25 +1,48 @@
 package account
 
 import (
-	"github.com/pkg/errors"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
 	"io"
+	"log"
+	"math/big"
+	"net"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
+
+	"github.com/pkg/errors"
 )
 
-type mockRequesterError struct{}
+type (
+	mockRequesterError  struct{}
+	mockRoundTripper    struct{}
+	mockRequesterCancel struct {
+		cancel context.CancelFunc
+	}
+)
 
 var (
 	_clientWithBuildRequestError = httpclient{
 		errCtx: "http_client",
-		buildRequest: func(method, url string, body io.Reader) (*http.Request, error) {
+		buildRequest: func(ctx context.Context, method, url string, body io.Reader) (*http.Request, error) {
 			return nil, errors.New("error on buildRequest")
 		},
 	}
 	_clientWithDoError = httpclient{
 		errCtx:       "http_client",
-		buildRequest: func(method, url string, body io.Reader) (*http.Request, error) { return nil, nil },
-		requester:    mockRequesterError{},
+		buildRequest: func(ctx context.Context, method, url string, body io.Reader) (*http.Request, error) { return nil, nil },
+		Requester:    mockRequesterError{},
 	}
 )
 
@@ -33,13 +56,231 @@ func TestRequest_Error(t *testing.T) {
 		{"do", _clientWithDoError, errors.New("http_client#request() do: error on do")},
 	}
 	for _, tt := range cases {
-		_, got := tt.in.request("method", "url", nil)
+		_, got := tt.in.request(context.Background(), "method", "url", nil)
 		if got.Error() != tt.want.Error() {
 			t.Errorf("Request_Error(%v) got: %v, want: %v", tt.name, got, tt.want)
 		}
 	}
 }
 
+func TestRequest_Context(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	expired, cancelExpired := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancelExpired()
+	client := httpclient{
+		errCtx:       "http_client",
+		buildRequest: http.NewRequestWithContext,
+		Requester:    mockRequesterError{},
+	}
+	cases := []struct {
+		name string
+		in   context.Context
+		want error
+	}{
+		{"canceled", canceled, context.Canceled},
+		{"deadline exceeded", expired, context.DeadlineExceeded},
+	}
+
+	for _, tt := range cases {
+		_, got := client.request(tt.in, _get, "http://localhost", nil)
+		if !errors.Is(got, tt.want) {
+			t.Errorf("Request_Context(%v) got: %v, want: %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRequest_ContextDuringDo(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	client := httpclient{
+		errCtx:       "http_client",
+		buildRequest: http.NewRequestWithContext,
+		Requester:    mockRequesterCancel{cancel: cancel},
+	}
+
+	_, got := client.request(ctx, _get, "http://localhost", nil)
+	if !errors.Is(got, context.Canceled) {
+		t.Errorf("Request_ContextDuringDo got: %v, want: %v", got, context.Canceled)
+	}
+}
+
+func TestNewStdClient(t *testing.T) {
+	base := &http.Client{Timeout: time.Second}
+	tlsConfig := &tls.Config{ServerName: "accountapi"}
+
+	got, err := newStdClient(httpOptions{httpClient: base, timeout: time.Minute, tlsConfig: tlsConfig})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got == base || base.Timeout != time.Second || base.Transport != nil {
+		t.Errorf("NewStdClient changed the given http.Client: %v", base)
+	}
+	if got.Timeout != time.Minute {
+		t.Errorf("NewStdClient Timeout got: %v, want: %v", got.Timeout, time.Minute)
+	}
+	if tr, ok := got.Transport.(*http.Transport); !ok || tr.TLSClientConfig.ServerName != "accountapi" {
+		t.Errorf("NewStdClient Transport got: %v", got.Transport)
+	}
+}
+
+func TestNewHTTPClient_Error(t *testing.T) {
+	cases := []struct {
+		name string
+		in   httpOptions
+		want string
+	}{
+		{"custom transport", httpOptions{httpClient: &http.Client{Transport: mockRoundTripper{}}, tlsConfig: &tls.Config{}}, "http_client#newHTTPClient(): tls: transport account.mockRoundTripper is not a *http.Transport"},
+		{"client certificate", httpOptions{clientCert: &certificateFiles{cert: "missing.pem", key: "missing.key"}}, "http_client#newHTTPClient(): tls: load client certificate: open missing.pem: no such file or directory"},
+	}
+
+	for _, tt := range cases {
+		_, got := newHTTPClient(tt.in).request(context.Background(), _get, "http://localhost", nil)
+		if got == nil || got.Error() != "http_client#request() do: "+tt.want {
+			t.Errorf("NewHTTPClient_Error(%v) got: %v, want: %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestNewTLSConfig_CA(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile, _, _ := writeTestCertificates(t, dir)
+	invalidCA := filepath.Join(dir, "invalid.pem")
+	if err := os.WriteFile(invalidCA, []byte("invalid"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"missing", filepath.Join(dir, "missing.pem"), "tls: read CA: open " + filepath.Join(dir, "missing.pem") + ": no such file or directory"},
+		{"invalid", invalidCA, "tls: no certificate found in CA " + invalidCA},
+	}
+
+	for _, tt := range cases {
+		_, got := newTLSConfig(httpOptions{clientCert: &certificateFiles{cert: certFile, key: keyFile, ca: tt.in}})
+		if got == nil || got.Error() != tt.want {
+			t.Errorf("NewTLSConfig_CA(%v) got: %v, want: %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestClientCertificate(t *testing.T) {
+	certFile, keyFile, caFile, serverTLS := writeTestCertificates(t, t.TempDir())
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	srv.TLS = serverTLS
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0)
+	srv.StartTLS()
+	defer srv.Close()
+	baseURL, _ := url.Parse(srv.URL)
+
+	withCert := NewHTTPRepository(WithBaseURL(baseURL), WithTimeout(5*time.Second), WithClientCertificate(certFile, keyFile, caFile))
+	if _, err := withCert.Health(context.Background()); err != nil {
+		t.Errorf("ClientCertificate got: %v, want: nil", err)
+	}
+
+	pool := x509.NewCertPool()
+	pool.AppendCertsFromPEM(mustReadFile(t, caFile))
+	withoutCert := NewHTTPRepository(WithBaseURL(baseURL), WithTLSConfig(&tls.Config{RootCAs: pool}))
+	if _, err := withoutCert.Health(context.Background()); err == nil {
+		t.Errorf("ClientCertificate without certificate got: nil, want: error")
+	}
+}
+
+// writeTestCertificates writes a CA and a client certificate signed by it to dir. It returns the file names and a
+// server TLS configuration for 127.0.0.1, signed by the same CA, that requires client certificates.
+func writeTestCertificates(t *testing.T, dir string) (certFile, keyFile, caFile string, serverTLS *tls.Config) {
+	t.Helper()
+	newCert := func(tmpl *x509.Certificate, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey, []byte) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if parent == nil {
+			parent, parentKey = tmpl, key
+		}
+		der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, parentKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return cert, key, der
+	}
+	writePEM := func(name, typ string, b []byte) string {
+		file := filepath.Join(dir, name)
+		if err := os.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: b}), 0600); err != nil {
+			t.Fatal(err)
+		}
+		return file
+	}
+	notAfter := time.Now().Add(time.Hour)
+
+	ca, caKey, caDER := newCert(&x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "account-lib test CA"},
+		NotAfter:              notAfter,
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}, nil, nil)
+	_, clientKey, clientDER := newCert(&x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "account-lib test client"},
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, ca, caKey)
+	_, serverKey, serverDER := newCert(&x509.Certificate{
+		SerialNumber: big.NewInt(3),
+		Subject:      pkix.Name{CommonName: "account-lib test server"},
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}, ca, caKey)
+
+	clientKeyDER, err := x509.MarshalECPrivateKey(clientKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(ca)
+
+	return writePEM("client.pem", "CERTIFICATE", clientDER),
+		writePEM("client.key", "EC PRIVATE KEY", clientKeyDER),
+		writePEM("ca.pem", "CERTIFICATE", caDER),
+		&tls.Config{
+			Certificates: []tls.Certificate{{Certificate: [][]byte{serverDER}, PrivateKey: serverKey}},
+			ClientAuth:   tls.RequireAndVerifyClientCert,
+			ClientCAs:    pool,
+		}
+}
+
+func mustReadFile(t *testing.T, name string) []byte {
+	t.Helper()
+	b, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return b
+}
+
+func (m mockRoundTripper) RoundTrip(_ *http.Request) (*http.Response, error) {
+	return nil, errors.New("error on round trip")
+}
+
 func (m mockRequesterError) Do(_ *http.Request) (*http.Response, error) {
 	return nil, errors.New("error on do")
 }
+
+func (m mockRequesterCancel) Do(_ *http.Request) (*http.Response, error) {
+	m.cancel()
+	return nil, errors.New("error on do")
+}
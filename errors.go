@@ -0,0 +1,198 @@
+package account
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type (
+	// Operation identifies the account-api call that produced an APIError.
+	Operation string
+	// APIError describes an unsuccessful response of account-api. It is returned wrapped by every Service method, so
+	// it must be retrieved with errors.As:
+	//
+	//	var apiErr *account.APIError
+	//	if errors.As(err, &apiErr) {
+	//		log.Println(apiErr.StatusCode, apiErr.Code, apiErr.Header.Get("X-Request-Id"))
+	//	}
+	//
+	// APIError also matches the status class sentinels (ErrBadRequest, ErrNotFound, ErrServer...) with errors.Is.
+	APIError struct {
+		// StatusCode is the HTTP status code of the response.
+		StatusCode int
+		// Code is the error_code attribute of the response body, if any.
+		Code string
+		// Message is the error_message attribute of the response body. When the body is not a JSON error document
+		// Message holds the raw body instead.
+		Message string
+		// Operation is the call that failed.
+		Operation Operation
+		// ResourceID is the ID of the account involved in the call, if any.
+		ResourceID string
+		// Header holds the response headers.
+		Header http.Header
+	}
+	// VersionConflictError is returned when account-api refuses a change because the account is not in the expected
+	// version anymore, i.e. it has been changed by someone else. It matches ErrVersionConflict with errors.Is and
+	// unwraps to the APIError returned by account-api.
+	VersionConflictError struct {
+		// ID of the account.
+		ID string
+		// Version is the version the caller expected the account to be in.
+		Version int64
+		// Err is the APIError returned by account-api.
+		Err *APIError
+	}
+	// CircuitOpenError is returned without calling account-api while the circuit breaker is open. It matches
+	// ErrCircuitOpen with errors.Is.
+	CircuitOpenError struct {
+		// RetryAt is when the circuit breaker probes account-api again.
+		RetryAt time.Time
+	}
+)
+
+const (
+	// OperationCreate identifies Service.Create calls.
+	OperationCreate Operation = "create"
+	// OperationFetch identifies Service.Fetch calls.
+	OperationFetch Operation = "fetch"
+	// OperationDelete identifies Service.Delete calls.
+	OperationDelete Operation = "delete"
+	// OperationList identifies Service.List calls.
+	OperationList Operation = "list"
+	// OperationUpdate identifies Service.Update calls.
+	OperationUpdate Operation = "update"
+	// OperationHealth identifies health checks of account-api.
+	OperationHealth Operation = "health"
+)
+
+const _maxErrorBodySize = 64 << 10
+
+var (
+	// ErrBadRequest matches APIError with 400 status code.
+	ErrBadRequest = errors.New("bad request")
+	// ErrUnauthorized matches APIError with 401 status code.
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrForbidden matches APIError with 403 status code.
+	ErrForbidden = errors.New("forbidden")
+	// ErrNotFound matches APIError with 404 status code, returned when account-api does not know the requested
+	// account. The requested ID is kept in APIError.ResourceID. It must be checked with errors.Is:
+	//
+	//	if _, err := svc.Fetch(id); errors.Is(err, account.ErrNotFound) {
+	//		// the account does not exist
+	//	}
+	ErrNotFound = errors.New("account not found")
+	// ErrConflict matches APIError with 409 status code.
+	ErrConflict = errors.New("conflict")
+	// ErrTooManyRequests matches APIError with 429 status code.
+	ErrTooManyRequests = errors.New("too many requests")
+	// ErrServer matches APIError with any 5xx status code.
+	ErrServer = errors.New("server error")
+	// ErrVersionConflict matches VersionConflictError. Use errors.As to retrieve the expected version.
+	ErrVersionConflict = errors.New("version conflict")
+	// ErrCircuitOpen matches CircuitOpenError. Use errors.As to retrieve when account-api is probed again.
+	ErrCircuitOpen = errors.New("circuit open")
+	// ErrCassetteMiss is returned by a Cassette in CassetteReplay mode when no recorded exchange matches a request.
+	ErrCassetteMiss = errors.New("no recorded exchange matches the request")
+	// ErrInvalidRequest matches ValidationError, returned without calling account-api when a request is invalid.
+	ErrInvalidRequest = errors.New("invalid request")
+	// ErrUnhealthy is returned when account-api answers a health check with a status other than up.
+	ErrUnhealthy = errors.New("unhealthy")
+)
+
+// Error formats APIError as: account_api <operation>: id: <id>: status_code: <code>: <message>
+func (e *APIError) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = http.StatusText(e.StatusCode)
+	}
+	if e.Code != "" {
+		msg = fmt.Sprintf("%s: %s", e.Code, msg)
+	}
+
+	return fmt.Sprintf("account_api %s: id: %s: status_code: %d: %s", e.Operation, e.ResourceID, e.StatusCode, msg)
+}
+
+// Is reports whether the status code of APIError belongs to the class represented by target.
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrBadRequest:
+		return e.StatusCode == http.StatusBadRequest
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	case ErrForbidden:
+		return e.StatusCode == http.StatusForbidden
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrConflict:
+		return e.StatusCode == http.StatusConflict
+	case ErrTooManyRequests:
+		return e.StatusCode == http.StatusTooManyRequests
+	case ErrServer:
+		return e.StatusCode >= http.StatusInternalServerError
+	default:
+		return false
+	}
+}
+
+// Error formats VersionConflictError as: id: <id>: version: <version>: version conflict: <APIError>
+func (e *VersionConflictError) Error() string {
+	return fmt.Sprintf("id: %s: version: %d: %v: %v", e.ID, e.Version, ErrVersionConflict, e.Err)
+}
+
+// Is reports whether target is ErrVersionConflict.
+func (e *VersionConflictError) Is(target error) bool {
+	return target == ErrVersionConflict
+}
+
+// Unwrap returns the APIError returned by account-api.
+func (e *VersionConflictError) Unwrap() error {
+	return e.Err
+}
+
+// Error formats CircuitOpenError as: account_api: circuit open: retry at: <time>
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("account_api: %v: retry at: %s", ErrCircuitOpen, e.RetryAt.Format(time.RFC3339))
+}
+
+// Is reports whether target is ErrCircuitOpen.
+func (e *CircuitOpenError) Is(target error) bool {
+	return target == ErrCircuitOpen
+}
+
+func newAPIError(resp *http.Response, op Operation, id string) *APIError {
+	type errorBody struct {
+		Code    string `json:"error_code"`
+		Message string `json:"error_message"`
+	}
+
+	apiErr := &APIError{
+		StatusCode: resp.StatusCode,
+		Operation:  op,
+		ResourceID: id,
+		Header:     resp.Header,
+	}
+	if resp.Body == nil {
+		return apiErr
+	}
+
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, _maxErrorBodySize))
+	if err != nil || len(raw) == 0 {
+		return apiErr
+	}
+
+	var body errorBody
+	if err := json.Unmarshal(raw, &body); err != nil {
+		apiErr.Message = string(raw)
+		return apiErr
+	}
+	apiErr.Code = body.Code
+	apiErr.Message = body.Message
+
+	return apiErr
+}
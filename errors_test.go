@@ -0,0 +1,89 @@
+package account
+
+import (
+	"bytes"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestAPIError_Is(t *testing.T) {
+	sentinels := []error{ErrBadRequest, ErrUnauthorized, ErrForbidden, ErrNotFound, ErrConflict, ErrTooManyRequests, ErrServer}
+	cases := []struct {
+		status int
+		want   error
+	}{
+		{400, ErrBadRequest},
+		{401, ErrUnauthorized},
+		{403, ErrForbidden},
+		{404, ErrNotFound},
+		{409, ErrConflict},
+		{429, ErrTooManyRequests},
+		{500, ErrServer},
+		{503, ErrServer},
+		{418, nil},
+	}
+
+	for _, tt := range cases {
+		var err error = errors.Wrap(&APIError{StatusCode: tt.status}, "wrapped")
+		for _, s := range sentinels {
+			if got := errors.Is(err, s); got != (s == tt.want) {
+				t.Errorf("APIError_Is(%d, %v) got: %v, want: %v", tt.status, s, got, s == tt.want)
+			}
+		}
+	}
+}
+
+func TestAPIError_Error(t *testing.T) {
+	cases := []struct {
+		name string
+		in   APIError
+		want string
+	}{
+		{"message", APIError{StatusCode: 400, Message: "validation failure", Operation: OperationCreate, ResourceID: "id"}, "account_api create: id: id: status_code: 400: validation failure"},
+		{"code", APIError{StatusCode: 409, Code: "duplicate", Message: "already exists", Operation: OperationCreate, ResourceID: "id"}, "account_api create: id: id: status_code: 409: duplicate: already exists"},
+		{"no message", APIError{StatusCode: 503, Operation: OperationFetch, ResourceID: "id"}, "account_api fetch: id: id: status_code: 503: Service Unavailable"},
+	}
+
+	for _, tt := range cases {
+		if got := tt.in.Error(); got != tt.want {
+			t.Errorf("APIError_Error(%v) got: %v, want: %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestNewAPIError(t *testing.T) {
+	header := http.Header{"X-Request-Id": []string{"42"}}
+	cases := []struct {
+		name string
+		body string
+		want APIError
+	}{
+		{"json", `{"error_code":"c0d3","error_message":"validation failure"}`, APIError{StatusCode: 400, Code: "c0d3", Message: "validation failure", Operation: OperationFetch, ResourceID: "id", Header: header}},
+		{"raw", "<html>bad gateway</html>", APIError{StatusCode: 400, Message: "<html>bad gateway</html>", Operation: OperationFetch, ResourceID: "id", Header: header}},
+		{"empty", "", APIError{StatusCode: 400, Operation: OperationFetch, ResourceID: "id", Header: header}},
+	}
+
+	for _, tt := range cases {
+		resp := &http.Response{StatusCode: 400, Header: header, Body: mockCloser{bytes.NewBufferString(tt.body)}}
+		if got := newAPIError(resp, OperationFetch, "id"); !reflect.DeepEqual(*got, tt.want) {
+			t.Errorf("NewAPIError(%v) got: %v, want: %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestService_APIError(t *testing.T) {
+	svc := NewService(httpRepository{errCtx: "http_repository", client: mockRequestBadRequest{}})
+
+	_, err := svc.Fetch(_fakeStubID)
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != 400 || apiErr.ResourceID != _fakeStubID || apiErr.Operation != OperationFetch {
+		t.Errorf("Service_APIError got: %v", err)
+	}
+	if !errors.Is(err, ErrBadRequest) {
+		t.Errorf("Service_APIError got: %v, want: %v", err, ErrBadRequest)
+	}
+}
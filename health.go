@@ -0,0 +1,160 @@
+package account
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type (
+	// HealthStatus is the outcome of a health check of account-api.
+	HealthStatus struct {
+		// Up reports whether account-api is healthy: it answered 200 and, if it reported a status, the status is up.
+		Up bool
+		// Status is the status reported by account-api in the response body, e.g. up, if any.
+		Status string
+		// StatusCode is the HTTP status code of the response, zero when no response was received.
+		StatusCode int
+		// ResponseTime is how long account-api took to answer, or to fail.
+		ResponseTime time.Duration
+		// Details is the raw response body.
+		Details []byte
+	}
+	// PollOptions configures how Service.WaitReady polls account-api.
+	PollOptions struct {
+		// Interval is the delay between the first and the second check. It doubles after each check. Default is
+		// 100ms.
+		Interval time.Duration
+		// MaxInterval caps the delay between checks. Default is 5s.
+		MaxInterval time.Duration
+		// Timeout bounds each check. Default is 0, no bound other than the context given to WaitReady.
+		Timeout time.Duration
+	}
+	healthChecker interface {
+		Health(ctx context.Context) (*HealthStatus, error)
+	}
+	healthResponse struct {
+		Status       string          `json:"status"`
+		StatusCode   int             `json:"status_code,omitempty"`
+		ResponseTime string          `json:"response_time"`
+		Details      json.RawMessage `json:"details,omitempty"`
+		Error        string          `json:"error,omitempty"`
+	}
+)
+
+const (
+	_healthUp   = "up"
+	_healthDown = "down"
+
+	_defaultPollInterval    = 100 * time.Millisecond
+	_defaultPollMaxInterval = 5 * time.Second
+)
+
+// Health checks the health of account-api. It always returns a HealthStatus, along with an error when account-api
+// is not up: an error wrapping APIError when it answers with a status code other than 200, wrapping ErrUnhealthy when
+// it reports a status other than up, or the request error when it does not answer.
+func (s Service) Health(ctx context.Context) (status *HealthStatus, err error) {
+	ctx, span := s.startSpan(ctx, OperationHealth)
+	defer func() { span.end(nil, err) }()
+
+	status, err = s.healthChecker.Health(ctx)
+	if status == nil {
+		status = &HealthStatus{}
+	}
+	if err != nil {
+		return status, errors.Wrapf(err, "%s health", s.errCtx)
+	}
+
+	return status, nil
+}
+
+// HealthHandler returns an http.Handler checking the health of account-api at each request, e.g. to be mounted as a
+// readiness probe dependency. It answers 200 when account-api is up and 503 otherwise, with a JSON body such as:
+//
+//	{"status":"up","status_code":200,"response_time":"3.2ms","details":{"status":"up"}}
+func (s Service) HealthHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status, err := s.Health(r.Context())
+
+		body := healthResponse{
+			Status:       _healthUp,
+			StatusCode:   status.StatusCode,
+			ResponseTime: status.ResponseTime.String(),
+		}
+		if json.Valid(status.Details) {
+			body.Details = status.Details
+		}
+		code := http.StatusOK
+		if err != nil {
+			body.Status = _healthDown
+			body.Error = err.Error()
+			code = http.StatusServiceUnavailable
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		_ = json.NewEncoder(w).Encode(body)
+	})
+}
+
+// WaitReady checks the health of account-api until it is up, waiting between checks according to PollOptions. It
+// returns nil as soon as account-api is up, or the error of the last check once ctx is done.
+//
+// Example:
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+//	defer cancel()
+//	if err := svc.WaitReady(ctx, acc.PollOptions{}); err != nil {
+//		log.Fatal(err)
+//	}
+func (s Service) WaitReady(ctx context.Context, opts PollOptions) error {
+	opts = opts.withDefaults()
+
+	var lastErr error
+	interval := opts.Interval
+	for {
+		err := s.check(ctx, opts.Timeout)
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() == nil || lastErr == nil {
+			lastErr = err
+		}
+
+		if err := sleep(ctx, interval); err != nil {
+			return errors.Wrapf(lastErr, "%s wait_ready: %v", s.errCtx, err)
+		}
+		if interval *= 2; interval > opts.MaxInterval {
+			interval = opts.MaxInterval
+		}
+	}
+}
+
+// check runs a health check bounded by timeout, if not zero.
+func (s Service) check(ctx context.Context, timeout time.Duration) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	_, err := s.Health(ctx)
+	return err
+}
+
+func (o PollOptions) withDefaults() PollOptions {
+	if o.Interval <= 0 {
+		o.Interval = _defaultPollInterval
+	}
+	if o.MaxInterval <= 0 {
+		o.MaxInterval = _defaultPollMaxInterval
+	}
+	if o.MaxInterval < o.Interval {
+		o.MaxInterval = o.Interval
+	}
+
+	return o
+}
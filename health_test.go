@@ -0,0 +1,135 @@
+package account
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// mockHealthChecker fails the first failures health checks, or all of them when failures is negative. When block is
+// set, failing checks wait for their context to be done.
+type mockHealthChecker struct {
+	failures int
+	block    bool
+	calls    int
+}
+
+func TestServiceHealth(t *testing.T) {
+	cases := []struct {
+		name    string
+		status  int
+		body    string
+		up      bool
+		state   string
+		wantErr error
+	}{
+		{"up", 200, `{"status":"up"}`, true, "up", nil},
+		{"no status", 200, ``, true, "", nil},
+		{"down", 200, `{"status":"down"}`, false, "down", ErrUnhealthy},
+		{"unavailable", 503, `{"status":"down"}`, false, "down", ErrServer},
+		{"not found", 404, `not found`, false, "", ErrNotFound},
+	}
+
+	for _, tt := range cases {
+		svc := Service{errCtx: "service", healthChecker: httpRepository{errCtx: "http_repository", client: mockRequestStatus{status: tt.status, body: tt.body}}}
+
+		got, err := svc.Health(context.Background())
+
+		if got.Up != tt.up || got.Status != tt.state || got.StatusCode != tt.status || string(got.Details) != tt.body {
+			t.Errorf("ServiceHealth(%v) got: %+v, want: up %v, status %v, status code %v, details %v", tt.name, got, tt.up, tt.state, tt.status, tt.body)
+		}
+		if (tt.wantErr == nil && err != nil) || (tt.wantErr != nil && !errors.Is(err, tt.wantErr)) {
+			t.Errorf("ServiceHealth(%v) error got: %v, want: %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestServiceHealth_Error(t *testing.T) {
+	svc := Service{errCtx: "service", healthChecker: _repositoryWithRequestError}
+	want := "service health: http_repository#Health() request: error on request"
+
+	got, err := svc.Health(context.Background())
+
+	if got == nil || got.Up || err == nil || err.Error() != want {
+		t.Errorf("ServiceHealth_Error got: %+v, %v, want: down, %v", got, err, want)
+	}
+}
+
+func TestHealthHandler(t *testing.T) {
+	cases := []struct {
+		name     string
+		status   int
+		body     string
+		wantCode int
+		want     healthResponse
+	}{
+		{"up", 200, `{"status":"up"}`, 200, healthResponse{Status: "up", StatusCode: 200, Details: json.RawMessage(`{"status":"up"}`)}},
+		{"down", 503, `maintenance`, 503, healthResponse{Status: "down", StatusCode: 503}},
+	}
+
+	for _, tt := range cases {
+		svc := Service{errCtx: "service", healthChecker: httpRepository{errCtx: "http_repository", client: mockRequestStatus{status: tt.status, body: tt.body}}}
+		rec := httptest.NewRecorder()
+
+		svc.HealthHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/ready", nil))
+
+		var got healthResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+			t.Fatal(err)
+		}
+		if rec.Code != tt.wantCode || rec.Header().Get("Content-Type") != "application/json" || got.Status != tt.want.Status ||
+			got.StatusCode != tt.want.StatusCode || string(got.Details) != string(tt.want.Details) || (got.Error == "") != (tt.wantCode == 200) {
+			t.Errorf("HealthHandler(%v) got: %d %+v, want: %d %+v", tt.name, rec.Code, got, tt.wantCode, tt.want)
+		}
+	}
+}
+
+func TestWaitReady(t *testing.T) {
+	checker := &mockHealthChecker{failures: 2}
+	svc := Service{errCtx: "service", healthChecker: checker}
+
+	if err := svc.WaitReady(context.Background(), PollOptions{Interval: time.Millisecond}); err != nil || checker.calls != 3 {
+		t.Errorf("WaitReady got: %v after %d checks, want: nil after 3 checks", err, checker.calls)
+	}
+}
+
+func TestWaitReady_Error(t *testing.T) {
+	checker := &mockHealthChecker{failures: -1}
+	svc := Service{errCtx: "service", healthChecker: checker}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	err := svc.WaitReady(ctx, PollOptions{Interval: time.Millisecond, MaxInterval: 2 * time.Millisecond})
+
+	if !errors.Is(err, ErrServer) || !strings.HasPrefix(err.Error(), "service wait_ready: context deadline exceeded: service health: ") || checker.calls < 2 {
+		t.Errorf("WaitReady_Error got: %v after %d checks, want: last check error", err, checker.calls)
+	}
+}
+
+func TestWaitReady_Timeout(t *testing.T) {
+	checker := &mockHealthChecker{failures: 1, block: true}
+	svc := Service{errCtx: "service", healthChecker: checker}
+
+	if err := svc.WaitReady(context.Background(), PollOptions{Interval: time.Millisecond, Timeout: 5 * time.Millisecond}); err != nil || checker.calls != 2 {
+		t.Errorf("WaitReady_Timeout got: %v after %d checks, want: nil after 2 checks", err, checker.calls)
+	}
+}
+
+func (m *mockHealthChecker) Health(ctx context.Context) (*HealthStatus, error) {
+	m.calls++
+	if m.failures >= 0 && m.calls > m.failures {
+		return &HealthStatus{Up: true, StatusCode: 200}, nil
+	}
+	if m.block {
+		<-ctx.Done()
+		return &HealthStatus{}, ctx.Err()
+	}
+
+	return &HealthStatus{StatusCode: 503}, &APIError{StatusCode: 503, Operation: OperationHealth}
+}
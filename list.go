@@ -0,0 +1,221 @@
+package account
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+type (
+	// ListOptions groups the parameters used to list accounts.
+	//
+	// See: https://api-docs.form3.tech/api.html#organisation-accounts-list
+	ListOptions struct {
+		// PageNumber is the zero based number of the page to be fetched. (OPTIONAL)
+		PageNumber int
+		// PageSize is the maximum number of accounts in a page. account-api default is used when zero. (OPTIONAL)
+		PageSize int
+		// Filter restricts the accounts listed. Empty attributes are not used. (OPTIONAL)
+		Filter ListFilter
+	}
+	// ListFilter groups the attributes accounts can be filtered by. All attributes must match.
+	ListFilter struct {
+		// BankID filters by local country bank identifier.
+		BankID string
+		// BankIDCode filters by the type of bank ID.
+		BankIDCode string
+		// AccountNumber filters by account number.
+		AccountNumber string
+		// Iban filters by IBAN.
+		Iban string
+		// Country filters by ISO 3166-1 country code.
+		Country string
+		// CustomerID filters by customer ID.
+		CustomerID string
+	}
+	// Page is a single page of accounts returned by Service.List.
+	Page struct {
+		// Accounts of the page in the order returned by account-api.
+		Accounts []*Entity
+		// Next holds the options to fetch the next page. It is nil when this is the last page.
+		Next *ListOptions
+	}
+	// Iterator walks through every account matching ListOptions, fetching pages on demand by following the links
+	// returned by account-api. It should be obtained through Service.Iterate:
+	//
+	//	it := svc.Iterate(ctx, account.ListOptions{PageSize: 100})
+	//	for it.Next() {
+	//		acc := it.Entity()
+	//	}
+	//	if err := it.Err(); err != nil {
+	//		// handle error
+	//	}
+	Iterator struct {
+		svc      Service
+		ctx      context.Context
+		opts     *ListOptions
+		accounts []*Entity
+		current  *Entity
+		err      error
+	}
+)
+
+const (
+	_pageNumberParam = "page[number]"
+	_pageSizeParam   = "page[size]"
+)
+
+var _filterParams = []struct {
+	name  string
+	value func(f *ListFilter) *string
+}{
+	{"filter[bank_id]", func(f *ListFilter) *string { return &f.BankID }},
+	{"filter[bank_id_code]", func(f *ListFilter) *string { return &f.BankIDCode }},
+	{"filter[account_number]", func(f *ListFilter) *string { return &f.AccountNumber }},
+	{"filter[iban]", func(f *ListFilter) *string { return &f.Iban }},
+	{"filter[country]", func(f *ListFilter) *string { return &f.Country }},
+	{"filter[customer_id]", func(f *ListFilter) *string { return &f.CustomerID }},
+}
+
+// List gets a page of accounts matching ListOptions. Page.Next holds the options to fetch the following page.
+//
+// See: https://api-docs.form3.tech/api.html#organisation-accounts-list
+func (s Service) List(opts ListOptions) (*Page, error) {
+	return s.ListContext(context.Background(), opts)
+}
+
+// ListContext is like List but bounds the call to ctx. If ctx is cancelled or its deadline expires before the
+// account-api answers, the returned error wraps context.Canceled or context.DeadlineExceeded.
+func (s Service) ListContext(ctx context.Context, opts ListOptions) (page *Page, err error) {
+	ctx, span := s.startSpan(ctx, OperationList)
+	defer func() { span.end(nil, err) }()
+
+	wrapErr := func(err error, msg string) error {
+		return errors.Wrapf(err, "%s list_%s: page_number: %d, page_size: %d", s.errCtx, msg, opts.PageNumber, opts.PageSize)
+	}
+
+	ret, err := s.lister.List(ctx, s.toQuery(opts))
+	if err != nil {
+		return nil, wrapErr(err, "repo_list")
+	}
+
+	page, err = s.ofPage(*ret)
+	if err != nil {
+		return nil, wrapErr(err, "ofPage")
+	}
+
+	return page, nil
+}
+
+// Iterate returns an Iterator over every account matching ListOptions, starting at ListOptions.PageNumber. Pages
+// are only fetched when Iterator.Next is called, bound to ctx.
+func (s Service) Iterate(ctx context.Context, opts ListOptions) *Iterator {
+	return &Iterator{
+		svc:  s,
+		ctx:  ctx,
+		opts: &opts,
+	}
+}
+
+// Next advances the Iterator to the next account, fetching the next page when needed. It returns false when there
+// are no more accounts or an error happens. Err must be checked after Next returns false.
+func (it *Iterator) Next() bool {
+	for len(it.accounts) == 0 {
+		if it.err != nil || it.opts == nil {
+			return false
+		}
+
+		page, err := it.svc.ListContext(it.ctx, *it.opts)
+		if err != nil {
+			it.err = err
+			return false
+		}
+
+		it.accounts, it.opts = page.Accounts, page.Next
+		if len(page.Accounts) == 0 {
+			it.opts = nil
+		}
+	}
+
+	it.current, it.accounts = it.accounts[0], it.accounts[1:]
+
+	return true
+}
+
+// Entity returns the account the Iterator is positioned at.
+func (it *Iterator) Entity() *Entity {
+	return it.current
+}
+
+// Err returns the error that stopped the Iterator, if any.
+func (it *Iterator) Err() error {
+	return it.err
+}
+
+func (r mapper) toQuery(opts ListOptions) url.Values {
+	query := url.Values{}
+	if opts.PageNumber > 0 {
+		query.Set(_pageNumberParam, strconv.Itoa(opts.PageNumber))
+	}
+	if opts.PageSize > 0 {
+		query.Set(_pageSizeParam, strconv.Itoa(opts.PageSize))
+	}
+	for _, p := range _filterParams {
+		if v := *p.value(&opts.Filter); v != "" {
+			query.Set(p.name, v)
+		}
+	}
+
+	return query
+}
+
+func (r mapper) ofQuery(query url.Values) (*ListOptions, error) {
+	var opts ListOptions
+	if v := query.Get(_pageNumberParam); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, errors.Wrapf(err, "%s parse: %s", _pageNumberParam, v)
+		}
+		opts.PageNumber = n
+	}
+	if v := query.Get(_pageSizeParam); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, errors.Wrapf(err, "%s parse: %s", _pageSizeParam, v)
+		}
+		opts.PageSize = n
+	}
+	for _, p := range _filterParams {
+		*p.value(&opts.Filter) = query.Get(p.name)
+	}
+
+	return &opts, nil
+}
+
+func (r mapper) ofPage(lp AccountList) (*Page, error) {
+	accounts := make([]*Entity, 0, len(lp.Data))
+	for _, d := range lp.Data {
+		acc, err := r.ofAcc(d)
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, acc)
+	}
+
+	page := &Page{Accounts: accounts}
+	if lp.Links == nil || lp.Links.Next == "" {
+		return page, nil
+	}
+
+	next, err := url.Parse(lp.Links.Next)
+	if err != nil {
+		return nil, errors.Wrapf(err, "links.next parse: %s", lp.Links.Next)
+	}
+	if page.Next, err = r.ofQuery(next.Query()); err != nil {
+		return nil, err
+	}
+
+	return page, nil
+}
@@ -0,0 +1,177 @@
+package account
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+type mockLister struct {
+	pages map[string]AccountList
+}
+
+var (
+	_fullyFilledListOptions = ListOptions{
+		PageNumber: 2,
+		PageSize:   50,
+		Filter: ListFilter{
+			BankID:        _bankIDStub,
+			BankIDCode:    _bankIDCodeStub,
+			AccountNumber: _numberStub,
+			Iban:          _ibanStub,
+			Country:       _countryStub,
+			CustomerID:    "42",
+		},
+	}
+	_fullyFilledQuery = url.Values{
+		"page[number]":           []string{"2"},
+		"page[size]":             []string{"50"},
+		"filter[bank_id]":        []string{_bankIDStub},
+		"filter[bank_id_code]":   []string{_bankIDCodeStub},
+		"filter[account_number]": []string{_numberStub},
+		"filter[iban]":           []string{_ibanStub},
+		"filter[country]":        []string{_countryStub},
+		"filter[customer_id]":    []string{"42"},
+	}
+	_mockedLister = mockLister{pages: map[string]AccountList{
+		"0": {Data: []AccountData{_fullyFilledData, _basicFilledData}, Links: &ListLinks{Next: "/v1/organisation/accounts?page%5Bnumber%5D=1&page%5Bsize%5D=2"}},
+		"1": {Data: []AccountData{_fullyFilledData}, Links: &ListLinks{Next: "/v1/organisation/accounts?page%5Bnumber%5D=2&page%5Bsize%5D=2"}},
+		"2": {Data: []AccountData{}, Links: &ListLinks{Next: "/v1/organisation/accounts?page%5Bnumber%5D=3&page%5Bsize%5D=2"}},
+	}}
+	_serviceWithMockedLister = Service{
+		errCtx:       "service",
+		inputMapper:  mapper{},
+		outputMapper: mapper{},
+		lister:       _mockedLister,
+	}
+)
+
+func TestToQuery(t *testing.T) {
+	cases := []struct {
+		name string
+		in   ListOptions
+		want url.Values
+	}{
+		{"fully filled", _fullyFilledListOptions, _fullyFilledQuery},
+		{"empty", ListOptions{}, url.Values{}},
+	}
+	m := mapper{}
+
+	for _, tt := range cases {
+		if got := m.toQuery(tt.in); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ToQuery(%v) got: %v, want: %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestOfQuery(t *testing.T) {
+	m := mapper{}
+
+	got, err := m.ofQuery(_fullyFilledQuery)
+	if err != nil || !reflect.DeepEqual(*got, _fullyFilledListOptions) {
+		t.Errorf("OfQuery got: %v, want: %v", got, _fullyFilledListOptions)
+	}
+}
+
+func TestOfPage_Error(t *testing.T) {
+	cases := []struct {
+		name string
+		in   AccountList
+		want error
+	}{
+		{"ofAcc", AccountList{Data: []AccountData{_dataWithIDErr}}, errors.New("ID parse: 3rr0r: invalid UUID length: 5")},
+		{"links.next", AccountList{Links: &ListLinks{Next: "%zz"}}, errors.New(`links.next parse: %zz: parse "%zz": invalid URL escape "%zz"`)},
+		{"page number", AccountList{Links: &ListLinks{Next: "/?page%5Bnumber%5D=last"}}, errors.New(`page[number] parse: last: strconv.Atoi: parsing "last": invalid syntax`)},
+		{"page size", AccountList{Links: &ListLinks{Next: "/?page%5Bsize%5D=big"}}, errors.New(`page[size] parse: big: strconv.Atoi: parsing "big": invalid syntax`)},
+	}
+	m := mapper{}
+
+	for _, tt := range cases {
+		_, got := m.ofPage(tt.in)
+		if got == nil || got.Error() != tt.want.Error() {
+			t.Errorf("OfPage_Error(%v) got: %v, want: %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAccountList(t *testing.T) {
+	want := &Page{
+		Accounts: []*Entity{_fullyFilledEntity, _basicFilledEntity},
+		Next:     &ListOptions{PageNumber: 1, PageSize: 2},
+	}
+
+	got, err := _serviceWithMockedLister.List(ListOptions{})
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("List got: %v, want: %v", got, want)
+	}
+}
+
+func TestList_Error(t *testing.T) {
+	cases := []struct {
+		name string
+		in   Service
+		want error
+	}{
+		{"repo", _serviceWithRequestError, errors.New("service list_repo_list: page_number: 0, page_size: 0: repo list error")},
+		{"ofPage", Service{errCtx: "service", inputMapper: mockInputMapper{}, outputMapper: mockOutputMapperErr{}, lister: _mockedLister}, errors.New("service list_ofPage: page_number: 0, page_size: 0: ofPage error")},
+	}
+
+	for _, tt := range cases {
+		_, got := tt.in.List(ListOptions{})
+		if got == nil || got.Error() != tt.want.Error() {
+			t.Errorf("List_Error(%v) got: %v, want: %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestIterator(t *testing.T) {
+	want := []*Entity{_fullyFilledEntity, _basicFilledEntity, _fullyFilledEntity}
+
+	var got []*Entity
+	it := _serviceWithMockedLister.Iterate(context.Background(), ListOptions{})
+	for it.Next() {
+		got = append(got, it.Entity())
+	}
+
+	if it.Err() != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("Iterator got: %v, err: %v, want: %v", got, it.Err(), want)
+	}
+	if it.Next() {
+		t.Errorf("Iterator Next after end got: true, want: false")
+	}
+}
+
+func TestIterator_Error(t *testing.T) {
+	svc := _serviceWithMockedLister
+	svc.lister = mockLister{pages: map[string]AccountList{
+		"0": {Data: []AccountData{_fullyFilledData}, Links: &ListLinks{Next: "/v1/organisation/accounts?page%5Bnumber%5D=1"}},
+	}}
+	want := "service list_repo_list: page_number: 1, page_size: 0: page not found: 1"
+
+	it := svc.Iterate(context.Background(), ListOptions{})
+	n := 0
+	for it.Next() {
+		n++
+	}
+
+	if n != 1 || it.Err() == nil || it.Err().Error() != want {
+		t.Errorf("Iterator_Error got: %d, %v, want: 1, %v", n, it.Err(), want)
+	}
+}
+
+func (m mockLister) List(_ context.Context, query url.Values) (*AccountList, error) {
+	number := query.Get("page[number]")
+	if number == "" {
+		number = "0"
+	}
+
+	p, ok := m.pages[number]
+	if !ok {
+		return nil, fmt.Errorf("page not found: %s", number)
+	}
+
+	return &p, nil
+}
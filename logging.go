@@ -0,0 +1,170 @@
+package account
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type (
+	// LogOptions configures what is logged about each request sent to account-api.
+	LogOptions struct {
+		// Bodies enables logging of request and response bodies. Bodies that are not JSON documents are not logged,
+		// only their size. Default is false.
+		Bodies bool
+		// Redact holds the names of the JSON attributes whose values are masked in logged bodies, at any depth. The
+		// values of the list filters with these names, e.g. filter[iban], are masked in logged URLs. Default is
+		// name, alternative_names, iban, account_number and secondary_identification.
+		Redact []string
+	}
+	logger struct {
+		Requester
+		logger *slog.Logger
+		bodies bool
+		redact map[string]bool
+		now    func() time.Time
+	}
+)
+
+const (
+	_logMessage      = "account_api request"
+	_requestIDHeader = "X-Request-Id"
+	_redacted        = "[REDACTED]"
+)
+
+var _defaultRedact = []string{"name", "alternative_names", "iban", "account_number", "secondary_identification"}
+
+// LoggingMiddleware logs every request sent to account-api to l according to LogOptions, as WithLogger does.
+//
+// Each attempt of a request is logged once it is answered, with its method, URL, status code, latency, attempt number
+// and request ID. It is logged at info level, at warn level when the status code is 5xx and at error level when no
+// response is received.
+func LoggingMiddleware(l *slog.Logger, o LogOptions) Middleware {
+	fields := o.Redact
+	if fields == nil {
+		fields = _defaultRedact
+	}
+	redact := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		redact[f] = true
+	}
+
+	return func(next Requester) Requester {
+		return logger{
+			Requester: next,
+			logger:    l,
+			bodies:    o.Bodies,
+			redact:    redact,
+			now:       time.Now,
+		}
+	}
+}
+
+func (l logger) Do(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	attrs := []slog.Attr{
+		slog.String("method", req.Method),
+		slog.String("url", redactURL(req.URL, l.redact)),
+		slog.Int("attempt", attempt(ctx)),
+	}
+	if l.bodies && req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			attrs = append(attrs, slog.String("request_body", l.redactBody(body)))
+		}
+	}
+
+	start := l.now()
+	resp, err := l.Requester.Do(req)
+	attrs = append(attrs, slog.Duration("latency", l.now().Sub(start)))
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+		l.logger.LogAttrs(ctx, slog.LevelError, _logMessage, attrs...)
+		return nil, err
+	}
+
+	requestID := resp.Header.Get(_requestIDHeader)
+	if requestID == "" {
+		requestID = req.Header.Get(_requestIDHeader)
+	}
+	attrs = append(attrs, slog.Int("status", resp.StatusCode), slog.String("request_id", requestID))
+	if l.bodies && resp.Body != nil {
+		raw, readErr := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		resp.Body = io.NopCloser(bytes.NewReader(raw))
+		if readErr == nil {
+			attrs = append(attrs, slog.String("response_body", l.redactBody(io.NopCloser(bytes.NewReader(raw)))))
+		}
+	}
+
+	level := slog.LevelInfo
+	if resp.StatusCode >= http.StatusInternalServerError {
+		level = slog.LevelWarn
+	}
+	l.logger.LogAttrs(ctx, level, _logMessage, attrs...)
+
+	return resp, nil
+}
+
+// redactURL returns u with the values of the list filters named in redact masked.
+func redactURL(u *url.URL, redact map[string]bool) string {
+	redacted := *u
+	redacted.RawQuery = redactQuery(u.Query(), redact).Encode()
+
+	return redacted.String()
+}
+
+// redactQuery returns a copy of query with the values of the list filters named in redact masked.
+func redactQuery(query url.Values, redact map[string]bool) url.Values {
+	redacted := make(url.Values, len(query))
+	for k, v := range query {
+		if strings.HasPrefix(k, "filter[") && strings.HasSuffix(k, "]") && redact[k[len("filter["):len(k)-1]] {
+			v = []string{_redacted}
+		}
+		redacted[k] = v
+	}
+
+	return redacted
+}
+
+// redactBody reads body and returns it with the values of the redacted attributes masked.
+func (l logger) redactBody(body io.ReadCloser) string {
+	defer body.Close()
+
+	raw, err := io.ReadAll(body)
+	if err != nil || len(raw) == 0 {
+		return ""
+	}
+
+	var doc interface{}
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return "non-JSON body of " + strconv.Itoa(len(raw)) + " bytes"
+	}
+	redacted, _ := json.Marshal(l.redactValue(doc))
+
+	return string(redacted)
+}
+
+func (l logger) redactValue(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, e := range t {
+			if l.redact[k] {
+				t[k] = _redacted
+				continue
+			}
+			t[k] = l.redactValue(e)
+		}
+	case []interface{}:
+		for i, e := range t {
+			t[i] = l.redactValue(e)
+		}
+	}
+
+	return v
+}
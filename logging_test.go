@@ -0,0 +1,140 @@
+package account
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func TestWithLogger(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(_requestIDHeader, "req-1")
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"data":{"id":"ad27e265-9605-4b4b-a0e5-3003ea9cc4d2","attributes":{"name":["Jane Doe"],"iban":"GB11NWBK40030041426819","country":"GB"}}}`))
+	}))
+	defer srv.Close()
+	baseURL, _ := url.Parse(srv.URL)
+	var out bytes.Buffer
+	repo := NewHTTPRepository(
+		WithBaseURL(baseURL),
+		WithLogger(slog.New(slog.NewJSONHandler(&out, nil))),
+		WithBodyLogging(),
+		WithRetryPolicy(RetryPolicy{BaseDelay: time.Millisecond, RetryCreate: true}),
+	)
+
+	if _, err := repo.Create(context.Background(), _accountStub); err != nil {
+		t.Fatal(err)
+	}
+
+	records := decodeRecords(t, &out)
+	if len(records) != 2 {
+		t.Fatalf("WithLogger records got: %d, want: 2", len(records))
+	}
+	cases := []struct {
+		name  string
+		index int
+		key   string
+		want  interface{}
+	}{
+		{"first level", 0, "level", "WARN"},
+		{"first attempt", 0, "attempt", float64(1)},
+		{"first status", 0, "status", float64(503)},
+		{"second level", 1, "level", "INFO"},
+		{"second attempt", 1, "attempt", float64(2)},
+		{"second status", 1, "status", float64(201)},
+		{"method", 1, "method", _post},
+		{"url", 1, "url", srv.URL + "/v1/organisation/accounts"},
+		{"request id", 1, "request_id", "req-1"},
+		{"message", 1, "msg", _logMessage},
+	}
+	for _, tt := range cases {
+		if got := records[tt.index][tt.key]; got != tt.want {
+			t.Errorf("WithLogger(%v) got: %v, want: %v", tt.name, got, tt.want)
+		}
+	}
+	for _, key := range []string{"request_body", "response_body"} {
+		body, _ := records[1][key].(string)
+		if strings.Contains(body, "Jane") || strings.Contains(body, "GB11NWBK") || !strings.Contains(body, _redacted) {
+			t.Errorf("WithLogger(%v) got: %v, want: personal data redacted", key, body)
+		}
+	}
+	if body, _ := records[1]["response_body"].(string); !strings.Contains(body, `"country":"GB"`) {
+		t.Errorf("WithLogger(response_body) got: %v, want: country kept", body)
+	}
+}
+
+func TestLoggingMiddleware_Redact(t *testing.T) {
+	l := LoggingMiddleware(slog.Default(), LogOptions{Redact: []string{"bic"}})(nil).(logger)
+	cases := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"custom field", `{"data":{"attributes":{"bic":"NWBKGB22","name":["Jane"]}}}`, `{"data":{"attributes":{"bic":"[REDACTED]","name":["Jane"]}}}`},
+		{"nested in array", `{"data":[{"bic":"NWBKGB22"}]}`, `{"data":[{"bic":"[REDACTED]"}]}`},
+		{"not json", `Jane Doe`, `non-JSON body of 8 bytes`},
+		{"empty", ``, ``},
+	}
+
+	for _, tt := range cases {
+		if got := l.redactBody(mockCloser{bytes.NewBufferString(tt.in)}); got != tt.want {
+			t.Errorf("LoggingMiddleware_Redact(%v) got: %v, want: %v", tt.name, got, tt.want)
+		}
+	}
+
+	req, _ := http.NewRequest(_get, "http://localhost/v1/organisation/accounts?filter%5Bbic%5D=NWBKGB22&filter%5Bcountry%5D=GB", nil)
+	want := "http://localhost/v1/organisation/accounts?filter%5Bbic%5D=%5BREDACTED%5D&filter%5Bcountry%5D=GB"
+	if got := redactURL(req.URL, l.redact); got != want {
+		t.Errorf("LoggingMiddleware_Redact(url) got: %v, want: %v", got, want)
+	}
+}
+
+func TestLoggingMiddleware_Error(t *testing.T) {
+	var out bytes.Buffer
+	next := &mockScriptedRequester{responses: []mockResponse{{err: errors.New("connection refused")}}}
+	r := LoggingMiddleware(slog.New(slog.NewJSONHandler(&out, nil)), LogOptions{})(next)
+	req, _ := http.NewRequest(_get, "http://localhost/v1/health", nil)
+
+	if _, err := r.Do(req); err == nil {
+		t.Fatal("LoggingMiddleware_Error got: nil, want: connection refused")
+	}
+
+	records := decodeRecords(t, &out)
+	if len(records) != 1 || records[0]["level"] != "ERROR" || records[0]["error"] != "connection refused" {
+		t.Errorf("LoggingMiddleware_Error got: %v, want: an error record", records)
+	}
+	if _, ok := records[0]["request_body"]; ok {
+		t.Errorf("LoggingMiddleware_Error got: %v, want: no body logged", records[0])
+	}
+}
+
+func decodeRecords(t *testing.T, r *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+
+	var records []map[string]interface{}
+	dec := json.NewDecoder(r)
+	for dec.More() {
+		var rec map[string]interface{}
+		if err := dec.Decode(&rec); err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, rec)
+	}
+
+	return records
+}
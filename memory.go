@@ -0,0 +1,338 @@
+package account
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+// InMemoryRepository is a Repository keeping accounts in memory instead of calling account-api, so Service can be
+// exercised entirely in-process, e.g. in unit tests and local development. It is safe for concurrent use.
+//
+// It enforces the semantics of account-api and fails with the same APIError(s):
+//
+// Create fails with 409 when the ID is already used, and with 400 when the ID or OrganisationID is not a UUID or
+// Country is missing. Created accounts are in version 0 and have status confirmed.
+//
+// Fetch, Update and Delete fail with 404 when the account does not exist.
+//
+// Update and Delete fail with a VersionConflictError when the version given is not the current one. Update increments
+// the version.
+//
+// List pages accounts in creation order, 100 per page by default, and applies every ListFilter attribute but
+// CustomerID, which matches no account since accounts have no customer ID.
+//
+// Example:
+//
+//	svc := acc.NewService(acc.NewInMemoryRepository())
+type InMemoryRepository struct {
+	mu       sync.Mutex
+	accounts map[string]AccountData
+	ids      []string
+
+	errCtx string
+}
+
+const (
+	_defaultPageSize = 100
+	_statusConfirmed = "confirmed"
+)
+
+// NewInMemoryRepository instantiates an InMemoryRepository without accounts.
+func NewInMemoryRepository() *InMemoryRepository {
+	return &InMemoryRepository{
+		accounts: map[string]AccountData{},
+		errCtx:   "in_memory_repository",
+	}
+}
+
+// Create implements Repository.
+func (r *InMemoryRepository) Create(ctx context.Context, acc AccountData) (*AccountData, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, errors.Wrapf(err, "%s#Create() context", r.errCtx)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	badRequest := func(msg string) error {
+		return r.apiError(http.StatusBadRequest, OperationCreate, acc.ID, msg)
+	}
+
+	switch _, exists := r.accounts[acc.ID]; {
+	case !isUUID(acc.ID):
+		return nil, badRequest("id in body must be of type uuid: " + strconv.Quote(acc.ID))
+	case !isUUID(acc.OrganisationID):
+		return nil, badRequest("organisation_id in body must be of type uuid: " + strconv.Quote(acc.OrganisationID))
+	case acc.Attributes == nil || acc.Attributes.Country == nil || *acc.Attributes.Country == "":
+		return nil, badRequest("country in body is required")
+	case exists:
+		return nil, r.apiError(http.StatusConflict, OperationCreate, acc.ID, "Account cannot be created as it violates a duplicate constraint")
+	}
+
+	stored := cloneData(acc)
+	version, status := int64(0), _statusConfirmed
+	stored.Version = &version
+	stored.Attributes.Status = &status
+	stored.Type = "accounts"
+	r.accounts[acc.ID] = stored
+	r.ids = append(r.ids, acc.ID)
+
+	ret := cloneData(stored)
+	return &ret, nil
+}
+
+// Fetch implements Repository.
+func (r *InMemoryRepository) Fetch(ctx context.Context, id string) (*AccountData, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, errors.Wrapf(err, "%s#Fetch() context", r.errCtx)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, err := r.find(OperationFetch, id)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := cloneData(stored)
+	return &ret, nil
+}
+
+// Delete implements Repository.
+func (r *InMemoryRepository) Delete(ctx context.Context, id string, version int64) error {
+	if err := ctx.Err(); err != nil {
+		return errors.Wrapf(err, "%s#Delete() context", r.errCtx)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, err := r.find(OperationDelete, id)
+	if err != nil {
+		return err
+	}
+	if *stored.Version != version {
+		return r.versionConflict(OperationDelete, id, version)
+	}
+
+	delete(r.accounts, id)
+	for i, v := range r.ids {
+		if v == id {
+			r.ids = append(r.ids[:i], r.ids[i+1:]...)
+			break
+		}
+	}
+
+	return nil
+}
+
+// Update implements Repository.
+func (r *InMemoryRepository) Update(ctx context.Context, acc AccountData) (*AccountData, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, errors.Wrapf(err, "%s#Update() context", r.errCtx)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, err := r.find(OperationUpdate, acc.ID)
+	if err != nil {
+		return nil, err
+	}
+	if acc.Version != nil && *acc.Version != *stored.Version {
+		return nil, r.versionConflict(OperationUpdate, acc.ID, *acc.Version)
+	}
+
+	if patch := cloneData(acc).Attributes; patch != nil {
+		att := stored.Attributes
+		if patch.Name != nil {
+			att.Name = patch.Name
+		}
+		if patch.AlternativeNames != nil {
+			att.AlternativeNames = patch.AlternativeNames
+		}
+		if patch.SecondaryIdentification != nil {
+			att.SecondaryIdentification = patch.SecondaryIdentification
+		}
+		if patch.Switched != nil {
+			att.Switched = patch.Switched
+		}
+		if patch.MatchingOptOut != nil {
+			att.MatchingOptOut = patch.MatchingOptOut
+		}
+		if patch.Status != nil {
+			att.Status = patch.Status
+		}
+	}
+	version := *stored.Version + 1
+	stored.Version = &version
+	r.accounts[acc.ID] = stored
+
+	ret := cloneData(stored)
+	return &ret, nil
+}
+
+// List implements Repository.
+func (r *InMemoryRepository) List(ctx context.Context, query url.Values) (*AccountList, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, errors.Wrapf(err, "%s#List() context", r.errCtx)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	opts, err := mapper{}.ofQuery(query)
+	if err != nil {
+		return nil, r.apiError(http.StatusBadRequest, OperationList, "", err.Error())
+	}
+	if opts.PageSize <= 0 {
+		opts.PageSize = _defaultPageSize
+	}
+
+	matches := make([]AccountData, 0, len(r.ids))
+	for _, id := range r.ids {
+		if d := r.accounts[id]; matchFilter(d, opts.Filter) {
+			matches = append(matches, d)
+		}
+	}
+
+	ret := &AccountList{Data: []AccountData{}}
+	for i := opts.PageNumber * opts.PageSize; i >= 0 && i < len(matches) && len(ret.Data) < opts.PageSize; i++ {
+		ret.Data = append(ret.Data, cloneData(matches[i]))
+	}
+
+	last := 0
+	if len(matches) > 0 {
+		last = (len(matches) - 1) / opts.PageSize
+	}
+	pageLink := func(n int) string {
+		page := *opts
+		page.PageNumber = n
+		return "/" + strings.Join(_accountsPath, "/") + "?" + mapper{}.toQuery(page).Encode()
+	}
+	ret.Links = &ListLinks{First: pageLink(0), Last: pageLink(last), Self: pageLink(opts.PageNumber)}
+	if opts.PageNumber < last {
+		ret.Links.Next = pageLink(opts.PageNumber + 1)
+	}
+	if opts.PageNumber > 0 {
+		ret.Links.Prev = pageLink(opts.PageNumber - 1)
+	}
+
+	return ret, nil
+}
+
+// Health implements Repository, always reporting InMemoryRepository as up.
+func (r *InMemoryRepository) Health(ctx context.Context) (*HealthStatus, error) {
+	if err := ctx.Err(); err != nil {
+		return &HealthStatus{}, errors.Wrapf(err, "%s#Health() context", r.errCtx)
+	}
+
+	return &HealthStatus{
+		Up:         true,
+		Status:     _healthUp,
+		StatusCode: http.StatusOK,
+		Details:    []byte(fmt.Sprintf(`{"status":%q}`, _healthUp)),
+	}, nil
+}
+
+// find returns the account with id. r.mu must be held.
+func (r *InMemoryRepository) find(op Operation, id string) (AccountData, error) {
+	if !isUUID(id) {
+		return AccountData{}, r.apiError(http.StatusBadRequest, op, id, "id is not a valid uuid")
+	}
+	stored, ok := r.accounts[id]
+	if !ok {
+		return AccountData{}, r.apiError(http.StatusNotFound, op, id, fmt.Sprintf("record %s does not exist", id))
+	}
+
+	return stored, nil
+}
+
+func (r *InMemoryRepository) apiError(status int, op Operation, id, msg string) error {
+	return errors.Wrapf(&APIError{
+		StatusCode: status,
+		Message:    msg,
+		Operation:  op,
+		ResourceID: id,
+		Header:     http.Header{},
+	}, "%s#%s() status_code_verification", r.errCtx, op)
+}
+
+func (r *InMemoryRepository) versionConflict(op Operation, id string, version int64) error {
+	return errors.Wrapf(&VersionConflictError{
+		ID:      id,
+		Version: version,
+		Err: &APIError{
+			StatusCode: http.StatusConflict,
+			Message:    "invalid version",
+			Operation:  op,
+			ResourceID: id,
+			Header:     http.Header{},
+		},
+	}, "%s#%s() status_code_verification", r.errCtx, op)
+}
+
+func matchFilter(d AccountData, f ListFilter) bool {
+	att := d.Attributes
+	country := ""
+	if att.Country != nil {
+		country = *att.Country
+	}
+
+	return f.CustomerID == "" &&
+		(f.BankID == "" || f.BankID == att.BankID) &&
+		(f.BankIDCode == "" || f.BankIDCode == att.BankIDCode) &&
+		(f.AccountNumber == "" || f.AccountNumber == att.Number) &&
+		(f.Iban == "" || f.Iban == att.Iban) &&
+		(f.Country == "" || f.Country == country)
+}
+
+// cloneData returns a deep copy of d, so accounts kept in memory never share memory with callers.
+func cloneData(d AccountData) AccountData {
+	ret := d
+	ret.Version = clonePtr(d.Version)
+	if d.Attributes == nil {
+		return ret
+	}
+
+	att := *d.Attributes
+	att.Classification = clonePtr(att.Classification)
+	att.MatchingOptOut = clonePtr(att.MatchingOptOut)
+	att.Country = clonePtr(att.Country)
+	att.JointAccount = clonePtr(att.JointAccount)
+	att.SecondaryIdentification = clonePtr(att.SecondaryIdentification)
+	att.Status = clonePtr(att.Status)
+	att.Switched = clonePtr(att.Switched)
+	att.AlternativeNames = cloneSlice(att.AlternativeNames)
+	att.Name = cloneSlice(att.Name)
+	ret.Attributes = &att
+
+	return ret
+}
+
+func clonePtr[T any](p *T) *T {
+	if p == nil {
+		return nil
+	}
+	v := *p
+
+	return &v
+}
+
+// cloneSlice returns a copy of s, keeping nil and empty slices apart.
+func cloneSlice[T any](s []T) []T {
+	if s == nil {
+		return nil
+	}
+
+	return append([]T{}, s...)
+}
+
+func isUUID(s string) bool {
+	_, err := uuid.Parse(s)
+	return err == nil
+}
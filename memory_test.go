@@ -0,0 +1,136 @@
+package account
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+func TestInMemoryRepository(t *testing.T) {
+	svc := NewService(NewInMemoryRepository())
+
+	created, err := svc.Create(_basicFilledCreateRequest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created.ID() != _fakeStubID || created.Version() != 0 || created.Status() != Status(_statusConfirmed) || created.Iban() != _ibanStub {
+		t.Errorf("InMemoryRepository(create) got: %+v, want: version 0, status confirmed", created)
+	}
+
+	name := []string{"Jane Doe"}
+	updated, err := svc.Update(_fakeStubID, created.Version(), UpdateRequest{Name: name})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Version() != 1 || updated.Name()[0] != name[0] || updated.Iban() != _ibanStub {
+		t.Errorf("InMemoryRepository(update) got: %+v, want: version 1, name %v", updated, name)
+	}
+
+	name[0] = "changed by the caller"
+	fetched, err := svc.Fetch(_fakeStubID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fetched.Version() != 1 || fetched.Name()[0] != "Jane Doe" {
+		t.Errorf("InMemoryRepository(fetch) got: %+v, want: version 1, name Jane Doe", fetched)
+	}
+
+	if err := svc.Delete(fetched); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.Fetch(_fakeStubID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("InMemoryRepository(fetch deleted) got: %v, want: %v", err, ErrNotFound)
+	}
+}
+
+func TestInMemoryRepository_Error(t *testing.T) {
+	svc := NewService(NewInMemoryRepository(), WithValidation(false))
+	if _, err := svc.Create(_basicFilledCreateRequest); err != nil {
+		t.Fatal(err)
+	}
+	noCountry := _basicFilledCreateRequest
+	noCountry.ID, noCountry.Country = _idStub, ""
+	badID := _basicFilledCreateRequest
+	badID.ID = "not a uuid"
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	cases := []struct {
+		name string
+		call func() error
+		want error
+	}{
+		{"duplicate create", func() error { _, err := svc.Create(_basicFilledCreateRequest); return err }, ErrConflict},
+		{"create without country", func() error { _, err := svc.Create(noCountry); return err }, ErrBadRequest},
+		{"create with invalid ID", func() error { _, err := svc.Create(badID); return err }, ErrBadRequest},
+		{"fetch unknown", func() error { _, err := svc.Fetch(_idStub); return err }, ErrNotFound},
+		{"fetch invalid ID", func() error { _, err := svc.Fetch("not a uuid"); return err }, ErrBadRequest},
+		{"update unknown", func() error { _, err := svc.Update(_idStub, 0, UpdateRequest{}); return err }, ErrNotFound},
+		{"update stale version", func() error { _, err := svc.Update(_fakeStubID, 1, UpdateRequest{}); return err }, ErrVersionConflict},
+		{"delete unknown", func() error { return svc.Delete(BuildDeleteRequest(_idStub)) }, ErrNotFound},
+		{"delete stale version", func() error { return svc.Delete(&Entity{id: _fakeStubUUID, version: 1}) }, ErrVersionConflict},
+		{"cancelled", func() error { _, err := svc.FetchContext(cancelled, _fakeStubID); return err }, context.Canceled},
+	}
+
+	for _, tt := range cases {
+		if err := tt.call(); !errors.Is(err, tt.want) {
+			t.Errorf("InMemoryRepository_Error(%v) got: %v, want: %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestInMemoryRepository_List(t *testing.T) {
+	svc := NewService(NewInMemoryRepository())
+	var ids []string
+	for i := 0; i < 5; i++ {
+		cr := _basicFilledCreateRequest
+		cr.ID = uuid.NewString()
+		if i%2 == 1 {
+			cr.Country = "FR"
+		}
+		if _, err := svc.Create(cr); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, cr.ID)
+	}
+
+	cases := []struct {
+		name string
+		opts ListOptions
+		want []string
+	}{
+		{"all", ListOptions{}, ids},
+		{"paged", ListOptions{PageSize: 2}, ids},
+		{"second page", ListOptions{PageNumber: 1, PageSize: 2}, ids[2:]},
+		{"filtered", ListOptions{PageSize: 2, Filter: ListFilter{Country: "FR"}}, []string{ids[1], ids[3]}},
+		{"customer ID", ListOptions{Filter: ListFilter{CustomerID: "1"}}, nil},
+		{"beyond last page", ListOptions{PageNumber: 3, PageSize: 2}, nil},
+	}
+
+	for _, tt := range cases {
+		var got []string
+		it := svc.Iterate(context.Background(), tt.opts)
+		for it.Next() {
+			got = append(got, it.Entity().ID())
+		}
+		if it.Err() != nil || fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("InMemoryRepository_List(%v) got: %v, %v, want: %v", tt.name, got, it.Err(), tt.want)
+		}
+	}
+
+	page, err := svc.List(ListOptions{PageNumber: 2, PageSize: 2})
+	if err != nil || len(page.Accounts) != 1 || page.Next != nil {
+		t.Errorf("InMemoryRepository_List(last page) got: %+v, %v, want: 1 account, no next page", page, err)
+	}
+}
+
+func TestInMemoryRepository_Health(t *testing.T) {
+	svc := NewService(NewInMemoryRepository())
+
+	if got, err := svc.Health(context.Background()); err != nil || !got.Up || got.Status != _healthUp {
+		t.Errorf("InMemoryRepository_Health got: %+v, %v, want: up", got, err)
+	}
+}
@@ -0,0 +1,168 @@
+package account
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type (
+	// ErrorClass groups the errors of account-api calls for metrics.
+	ErrorClass string
+	// Metrics receives measurements of the account-api calls made by the HTTP repository, one per operation, retries
+	// included. Implementations must be safe for concurrent use.
+	//
+	// See: MetricsRecorder and the accountprom package for a Prometheus collector.
+	Metrics interface {
+		// CallStarted is called when a call of op starts.
+		CallStarted(op Operation)
+		// CallFinished is called when a call of op ends, after d, with the class of its error. class is
+		// ErrorClassNone when the call succeeded.
+		CallFinished(op Operation, d time.Duration, class ErrorClass)
+	}
+	// MetricsRecorder is a Metrics keeping measurements in memory, e.g. to check them in tests. Its zero value is
+	// ready to use.
+	MetricsRecorder struct {
+		mu        sync.Mutex
+		requests  map[Operation]int
+		errors    map[Operation]map[ErrorClass]int
+		latencies map[Operation][]time.Duration
+		inFlight  map[Operation]int
+	}
+	metricsOption struct{ Metrics }
+)
+
+const (
+	// ErrorClassNone is the class of successful calls.
+	ErrorClassNone ErrorClass = ""
+	// ErrorClassClient is the class of calls answered with a 4xx status code.
+	ErrorClassClient ErrorClass = "client"
+	// ErrorClassServer is the class of calls answered with a 5xx status code.
+	ErrorClassServer ErrorClass = "server"
+	// ErrorClassTimeout is the class of calls whose deadline expired or that timed out.
+	ErrorClassTimeout ErrorClass = "timeout"
+	// ErrorClassCanceled is the class of calls whose context was cancelled.
+	ErrorClassCanceled ErrorClass = "canceled"
+	// ErrorClassCircuitOpen is the class of calls refused by the circuit breaker.
+	ErrorClassCircuitOpen ErrorClass = "circuit_open"
+	// ErrorClassNetwork is the class of calls that received no response for another reason, e.g. connection refused.
+	ErrorClassNetwork ErrorClass = "network"
+	// ErrorClassOther is the class of the other errors, e.g. a response body that cannot be decoded.
+	ErrorClassOther ErrorClass = "other"
+)
+
+// WithMetrics reports the calls made to account-api to m. Nothing is reported by default.
+func WithMetrics(m Metrics) httpOption {
+	return metricsOption{m}
+}
+
+// ClassifyError returns the ErrorClass of err, an error returned by an account-api call.
+func ClassifyError(err error) ErrorClass {
+	var apiErr *APIError
+	var netErr net.Error
+
+	switch {
+	case err == nil:
+		return ErrorClassNone
+	case errors.As(err, &apiErr) && apiErr.StatusCode >= 500:
+		return ErrorClassServer
+	case errors.As(err, &apiErr):
+		return ErrorClassClient
+	case errors.Is(err, ErrCircuitOpen):
+		return ErrorClassCircuitOpen
+	case errors.Is(err, context.Canceled):
+		return ErrorClassCanceled
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return ErrorClassTimeout
+	case errors.As(err, &netErr):
+		return ErrorClassNetwork
+	default:
+		return ErrorClassOther
+	}
+}
+
+// track reports the start of a call of op to the metrics, if any, and returns the function reporting its end.
+func (r httpRepository) track(op Operation) func(err error) {
+	if r.metrics == nil {
+		return func(error) {}
+	}
+
+	start := time.Now()
+	r.metrics.CallStarted(op)
+
+	return func(err error) {
+		r.metrics.CallFinished(op, time.Since(start), ClassifyError(err))
+	}
+}
+
+// CallStarted records the start of a call of op.
+func (m *MetricsRecorder) CallStarted(op Operation) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.inFlight == nil {
+		m.inFlight = map[Operation]int{}
+	}
+	m.inFlight[op]++
+}
+
+// CallFinished records the end of a call of op.
+func (m *MetricsRecorder) CallFinished(op Operation, d time.Duration, class ErrorClass) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.requests == nil {
+		m.requests = map[Operation]int{}
+		m.errors = map[Operation]map[ErrorClass]int{}
+		m.latencies = map[Operation][]time.Duration{}
+	}
+	m.requests[op]++
+	m.latencies[op] = append(m.latencies[op], d)
+	m.inFlight[op]--
+	if class == ErrorClassNone {
+		return
+	}
+	if m.errors[op] == nil {
+		m.errors[op] = map[ErrorClass]int{}
+	}
+	m.errors[op][class]++
+}
+
+// Requests returns the number of finished calls of op.
+func (m *MetricsRecorder) Requests(op Operation) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.requests[op]
+}
+
+// Errors returns the number of finished calls of op that failed with an error of class.
+func (m *MetricsRecorder) Errors(op Operation, class ErrorClass) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.errors[op][class]
+}
+
+// Latencies returns the durations of the finished calls of op, in the order they finished.
+func (m *MetricsRecorder) Latencies(op Operation) []time.Duration {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]time.Duration(nil), m.latencies[op]...)
+}
+
+// InFlight returns the number of calls of op that have started but not finished yet.
+func (m *MetricsRecorder) InFlight(op Operation) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.inFlight[op]
+}
+
+func (m metricsOption) apply(opts *httpOptions) {
+	opts.metrics = m.Metrics
+}
@@ -0,0 +1,89 @@
+package account
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+type mockTimeoutError struct{}
+
+func TestWithMetrics(t *testing.T) {
+	recorder := &MetricsRecorder{}
+	var inFlight int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/v1/health":
+			inFlight = recorder.InFlight(OperationHealth)
+			w.WriteHeader(http.StatusOK)
+		case r.Method == _post:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case r.URL.Path == "/v1/organisation/accounts/"+_fakeStubID:
+			_, _ = w.Write([]byte(`{"data":{"id":"ad27e265-9605-4b4b-a0e5-3003ea9cc4d2"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	baseURL, _ := url.Parse(srv.URL)
+	repo := NewHTTPRepository(WithBaseURL(baseURL), WithMetrics(recorder))
+
+	_, _ = repo.Health(context.Background())
+	_, _ = repo.Fetch(context.Background(), _fakeStubID)
+	_, _ = repo.Fetch(context.Background(), _idStub)
+	_, _ = repo.Create(context.Background(), _accountStub)
+
+	cases := []struct {
+		name string
+		got  int
+		want int
+	}{
+		{"health in flight during call", inFlight, 1},
+		{"health in flight", recorder.InFlight(OperationHealth), 0},
+		{"health requests", recorder.Requests(OperationHealth), 1},
+		{"fetch requests", recorder.Requests(OperationFetch), 2},
+		{"fetch client errors", recorder.Errors(OperationFetch, ErrorClassClient), 1},
+		{"fetch latencies", len(recorder.Latencies(OperationFetch)), 2},
+		{"create requests", recorder.Requests(OperationCreate), 1},
+		{"create server errors", recorder.Errors(OperationCreate, ErrorClassServer), 1},
+		{"delete requests", recorder.Requests(OperationDelete), 0},
+	}
+	for _, tt := range cases {
+		if tt.got != tt.want {
+			t.Errorf("WithMetrics(%v) got: %v, want: %v", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestClassifyError(t *testing.T) {
+	cases := []struct {
+		name string
+		in   error
+		want ErrorClass
+	}{
+		{"nil", nil, ErrorClassNone},
+		{"client", errors.Wrap(&APIError{StatusCode: 404}, "fetch"), ErrorClassClient},
+		{"version conflict", &VersionConflictError{Err: &APIError{StatusCode: 409}}, ErrorClassClient},
+		{"server", errors.Wrap(&APIError{StatusCode: 502}, "fetch"), ErrorClassServer},
+		{"circuit open", errors.Wrap(&CircuitOpenError{}, "fetch"), ErrorClassCircuitOpen},
+		{"canceled", errors.Wrap(context.Canceled, "fetch"), ErrorClassCanceled},
+		{"deadline", errors.Wrap(context.DeadlineExceeded, "fetch"), ErrorClassTimeout},
+		{"client timeout", &url.Error{Op: "Get", URL: "http://localhost", Err: mockTimeoutError{}}, ErrorClassTimeout},
+		{"network", &url.Error{Op: "Get", URL: "http://localhost", Err: errors.New("connection refused")}, ErrorClassNetwork},
+		{"other", errors.New("decode"), ErrorClassOther},
+	}
+
+	for _, tt := range cases {
+		if got := ClassifyError(tt.in); got != tt.want {
+			t.Errorf("ClassifyError(%v) got: %v, want: %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func (mockTimeoutError) Error() string   { return "timeout" }
+func (mockTimeoutError) Timeout() bool   { return true }
+func (mockTimeoutError) Temporary() bool { return true }
@@ -0,0 +1,77 @@
+package account
+
+import "net/http"
+
+type (
+	// RequesterFunc adapts an ordinary function to Requester.
+	RequesterFunc func(req *http.Request) (*http.Response, error)
+	// Middleware wraps a Requester, adding behaviour around every request sent to account-api, e.g. headers, logging
+	// or metrics. Like http.RoundTripper, it must not modify the request it is given but a clone of it.
+	//
+	// Example:
+	//
+	//	tenant := func(next acc.Requester) acc.Requester {
+	//		return acc.RequesterFunc(func(req *http.Request) (*http.Response, error) {
+	//			req = req.Clone(req.Context())
+	//			req.Header.Set("X-Tenant", "payments")
+	//			return next.Do(req)
+	//		})
+	//	}
+	//	repository := NewHTTPRepository(acc.WithMiddleware(tenant))
+	Middleware func(next Requester) Requester
+)
+
+// Do calls f(req).
+func (f RequesterFunc) Do(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// chain wraps r with middlewares, the first one being the outermost.
+func chain(r Requester, middlewares ...Middleware) Requester {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		r = middlewares[i](r)
+	}
+
+	return r
+}
+
+// middlewares returns the middlewares enabled by opts, from the outermost to the innermost: retry, tracing, circuit
+// breaker, rate limit, max in flight, logging, the ones given to WithMiddleware, HTTP signature, OAuth2 and cassette.
+// Tracing, logging and custom middlewares thus see every attempt of a retried request, and the headers they add are
+// signed.
+func (o httpOptions) middlewares() []Middleware {
+	var m []Middleware
+	if o.retry != nil {
+		m = append(m, RetryMiddleware(*o.retry))
+	}
+	if o.tracerProvider != nil {
+		m = append(m, TracingMiddleware(o.tracerProvider))
+	}
+	if o.breaker != nil {
+		m = append(m, circuitBreakerMiddleware(*o.breaker, o.url()))
+	}
+	if l := o.rateLimit; l != nil && l.rate > 0 {
+		m = append(m, rateLimitMiddleware(l.rate, l.burst))
+	}
+	if o.inFlight > 0 {
+		m = append(m, maxInFlightMiddleware(o.inFlight))
+	}
+	if o.logger != nil {
+		m = append(m, LoggingMiddleware(o.logger, o.log))
+	}
+	m = append(m, o.middleware...)
+	if o.signature != nil {
+		signature := *o.signature
+		// The Authorization header holds the bearer token with OAuth2, so the signature goes to the Signature one.
+		signature.SignatureHeader = signature.SignatureHeader || o.oauth2 != nil
+		m = append(m, HTTPSignatureMiddleware(signature))
+	}
+	if o.oauth2 != nil {
+		m = append(m, oauth2Middleware(*o.oauth2))
+	}
+	if o.cassette != nil {
+		m = append(m, o.cassette.middleware)
+	}
+
+	return m
+}
@@ -0,0 +1,90 @@
+package account
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestChain(t *testing.T) {
+	var calls []string
+	trace := func(name string) Middleware {
+		return func(next Requester) Requester {
+			return RequesterFunc(func(req *http.Request) (*http.Response, error) {
+				calls = append(calls, name)
+				return next.Do(req)
+			})
+		}
+	}
+	next := &mockScriptedRequester{responses: []mockResponse{{status: 200}}}
+	req, _ := http.NewRequest(_get, "http://localhost", nil)
+
+	if _, err := chain(next, trace("first"), trace("second"), trace("third")).Do(req); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"first", "second", "third"}; !reflect.DeepEqual(calls, want) || len(next.methods) != 1 {
+		t.Errorf("Chain got: %v, want: %v", calls, want)
+	}
+}
+
+func TestWithMiddleware(t *testing.T) {
+	var attempts, tenants int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Tenant") == "payments" {
+			atomic.AddInt32(&tenants, 1)
+		}
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":{"id":"ad27e265-9605-4b4b-a0e5-3003ea9cc4d2"}}`))
+	}))
+	defer srv.Close()
+	baseURL, _ := url.Parse(srv.URL)
+	tenant := func(next Requester) Requester {
+		return RequesterFunc(func(req *http.Request) (*http.Response, error) {
+			req = req.Clone(req.Context())
+			req.Header.Set("X-Tenant", "payments")
+			return next.Do(req)
+		})
+	}
+	repo := NewHTTPRepository(WithBaseURL(baseURL), WithMiddleware(tenant), WithRetryPolicy(RetryPolicy{BaseDelay: time.Millisecond}))
+
+	if _, err := repo.Fetch(context.Background(), _fakeStubID); err != nil {
+		t.Fatalf("WithMiddleware got: %v, want: nil", err)
+	}
+	if got := atomic.LoadInt32(&tenants); got != 2 {
+		t.Errorf("WithMiddleware attempts with header got: %d, want: 2", got)
+	}
+}
+
+func TestWithMiddleware_Signed(t *testing.T) {
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	ecDER, _ := x509.MarshalECPrivateKey(ecKey)
+	hs := HTTPSignature{KeyID: "ec", PrivateKey: encodePEM("EC PRIVATE KEY", ecDER), Headers: []string{"(request-target)", "date", "digest", "x-tenant"}}
+	srv := httptest.NewServer(verifySignature(t, hs, &ecKey.PublicKey))
+	defer srv.Close()
+	baseURL, _ := url.Parse(srv.URL)
+	tenant := func(next Requester) Requester {
+		return RequesterFunc(func(req *http.Request) (*http.Response, error) {
+			req = req.Clone(req.Context())
+			req.Header.Set("X-Tenant", "payments")
+			return next.Do(req)
+		})
+	}
+	repo := NewHTTPRepository(WithBaseURL(baseURL), WithHTTPSignature(hs), WithMiddleware(tenant))
+
+	if _, err := repo.Fetch(context.Background(), _fakeStubID); err != nil {
+		t.Errorf("WithMiddleware_Signed got: %v, want: nil", err)
+	}
+}
@@ -0,0 +1,185 @@
+package account
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type (
+	// tokenSource fetches OAuth2 access tokens with the client credentials grant and caches them until shortly before
+	// they expire. It is safe for concurrent use: concurrent callers share a single token request.
+	tokenSource struct {
+		Requester
+		tokenURL string
+		clientID string
+		secret   string
+		scopes   []string
+		now      func() time.Time
+
+		// sem guards token. It is a channel instead of a mutex so that waiting for it respects context cancellation.
+		sem   chan struct{}
+		token *oauth2Token
+	}
+	oauth2Token struct {
+		accessToken string
+		expiry      time.Time
+	}
+	// oauth2Authorizer attaches a bearer token to every request. When account-api answers 401 the token is
+	// considered revoked: a fresh one is fetched and the request is sent once more.
+	oauth2Authorizer struct {
+		Requester
+		source *tokenSource
+	}
+)
+
+// _tokenExpiryDelta is how long before its expiry a token is refreshed, so it does not expire in flight.
+const _tokenExpiryDelta = 30 * time.Second
+
+func newTokenSource(next Requester, tokenURL, clientID, secret string, scopes []string) *tokenSource {
+	return &tokenSource{
+		Requester: next,
+		tokenURL:  tokenURL,
+		clientID:  clientID,
+		secret:    secret,
+		scopes:    scopes,
+		now:       time.Now,
+		sem:       make(chan struct{}, 1),
+	}
+}
+
+// Token returns the cached access token, fetching a new one when there is none or it is about to expire.
+func (ts *tokenSource) Token(ctx context.Context) (string, error) {
+	select {
+	case ts.sem <- struct{}{}:
+		defer func() { <-ts.sem }()
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+
+	if ts.token != nil && (ts.token.expiry.IsZero() || ts.now().Before(ts.token.expiry.Add(-_tokenExpiryDelta))) {
+		return ts.token.accessToken, nil
+	}
+
+	token, err := ts.fetch(ctx)
+	if err != nil {
+		return "", err
+	}
+	ts.token = token
+
+	return token.accessToken, nil
+}
+
+// invalidate drops the cached token if it is still stale, i.e. it has not been refreshed by another caller yet.
+func (ts *tokenSource) invalidate(stale string) {
+	ts.sem <- struct{}{}
+	defer func() { <-ts.sem }()
+
+	if ts.token != nil && ts.token.accessToken == stale {
+		ts.token = nil
+	}
+}
+
+func (ts *tokenSource) fetch(ctx context.Context) (*oauth2Token, error) {
+	type (
+		tokenResp struct {
+			AccessToken string `json:"access_token"`
+			TokenType   string `json:"token_type"`
+			ExpiresIn   int64  `json:"expires_in"`
+		}
+		errorResp struct {
+			Error       string `json:"error"`
+			Description string `json:"error_description"`
+		}
+	)
+
+	form := url.Values{"grant_type": []string{"client_credentials"}}
+	if len(ts.scopes) > 0 {
+		form.Set("scope", strings.Join(ts.scopes, " "))
+	}
+	req, err := http.NewRequestWithContext(ctx, _post, ts.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, errors.Wrap(err, "oauth2: token request")
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(ts.clientID), url.QueryEscape(ts.secret))
+
+	issuedAt := ts.now()
+	resp, err := ts.Requester.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "oauth2: token request")
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, _maxErrorBodySize))
+	if err != nil {
+		return nil, errors.Wrap(err, "oauth2: token response")
+	}
+	if resp.StatusCode != http.StatusOK {
+		var e errorResp
+		_ = json.Unmarshal(body, &e)
+		return nil, errors.Errorf("oauth2: token response: status_code: %d: %s: %s", resp.StatusCode, e.Error, e.Description)
+	}
+
+	var tr tokenResp
+	if err := json.Unmarshal(body, &tr); err != nil {
+		return nil, errors.Wrap(err, "oauth2: token response decode")
+	}
+	if tr.AccessToken == "" {
+		return nil, errors.New("oauth2: token response: access_token is empty")
+	}
+
+	token := &oauth2Token{accessToken: tr.AccessToken}
+	if tr.ExpiresIn > 0 {
+		token.expiry = issuedAt.Add(time.Duration(tr.ExpiresIn) * time.Second)
+	}
+
+	return token, nil
+}
+
+func oauth2Middleware(c oauth2Credentials) Middleware {
+	return func(next Requester) Requester {
+		return oauth2Authorizer{Requester: next, source: newTokenSource(next, c.tokenURL, c.clientID, c.secret, c.scopes)}
+	}
+}
+
+func (a oauth2Authorizer) Do(req *http.Request) (*http.Response, error) {
+	token, err := a.source.Token(req.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := a.Requester.Do(authorize(req, token))
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return resp, nil
+	}
+
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	a.source.invalidate(token)
+	if token, err = a.source.Token(req.Context()); err != nil {
+		return nil, err
+	}
+	retry, err := rewind(req, 2)
+	if err != nil {
+		return nil, errors.Wrap(err, "oauth2: rewind body")
+	}
+
+	return a.Requester.Do(authorize(retry, token))
+}
+
+func authorize(req *http.Request, token string) *http.Request {
+	authorized := req.Clone(req.Context())
+	authorized.Header.Set(_authHeader, "Bearer "+token)
+
+	return authorized
+}
@@ -0,0 +1,174 @@
+package account
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// mockTokenEndpoint acts as an OAuth2 token endpoint issuing token-1, token-2... that expire in expiresIn seconds.
+type mockTokenEndpoint struct {
+	issued    int32
+	expiresIn int
+	delay     time.Duration
+}
+
+func TestOAuth2ClientCredentials(t *testing.T) {
+	tokens := &mockTokenEndpoint{expiresIn: 3600}
+	tokenSrv := httptest.NewServer(tokens)
+	defer tokenSrv.Close()
+	apiSrv := httptest.NewServer(requireBearer(func(token string) bool { return token != "" }))
+	defer apiSrv.Close()
+	baseURL, _ := url.Parse(apiSrv.URL)
+	repo := NewHTTPRepository(WithBaseURL(baseURL), WithOAuth2ClientCredentials(tokenSrv.URL, "client", "s3cr3t", "accounts:read", "accounts:write"))
+
+	for i := 0; i < 3; i++ {
+		if _, err := repo.Fetch(context.Background(), _fakeStubID); err != nil {
+			t.Fatalf("OAuth2ClientCredentials got: %v, want: nil", err)
+		}
+	}
+
+	if got := atomic.LoadInt32(&tokens.issued); got != 1 {
+		t.Errorf("OAuth2ClientCredentials token requests got: %d, want: 1", got)
+	}
+}
+
+func TestOAuth2_Refresh(t *testing.T) {
+	tokens := &mockTokenEndpoint{expiresIn: 60}
+	tokenSrv := httptest.NewServer(tokens)
+	defer tokenSrv.Close()
+	ts := newTokenSource(http.DefaultClient, tokenSrv.URL, "client", "s3cr3t", nil)
+	now := time.Now()
+	ts.now = func() time.Time { return now }
+
+	first, _ := ts.Token(context.Background())
+	now = now.Add(29 * time.Second)
+	cached, _ := ts.Token(context.Background())
+	now = now.Add(2 * time.Second)
+	refreshed, err := ts.Token(context.Background())
+
+	if err != nil || first != "token-1" || cached != "token-1" || refreshed != "token-2" {
+		t.Errorf("OAuth2_Refresh got: %s, %s, %s, %v, want: token-1, token-1, token-2", first, cached, refreshed, err)
+	}
+}
+
+func TestOAuth2_Unauthorized(t *testing.T) {
+	tokens := &mockTokenEndpoint{expiresIn: 3600}
+	tokenSrv := httptest.NewServer(tokens)
+	defer tokenSrv.Close()
+	apiSrv := httptest.NewServer(requireBearer(func(token string) bool { return token == "token-2" }))
+	defer apiSrv.Close()
+	baseURL, _ := url.Parse(apiSrv.URL)
+	repo := NewHTTPRepository(WithBaseURL(baseURL), WithOAuth2ClientCredentials(tokenSrv.URL, "client", "s3cr3t"))
+
+	if _, err := repo.Create(context.Background(), _accountStub); err != nil {
+		t.Errorf("OAuth2_Unauthorized create got: %v, want: nil", err)
+	}
+	if got := atomic.LoadInt32(&tokens.issued); got != 2 {
+		t.Errorf("OAuth2_Unauthorized token requests got: %d, want: 2", got)
+	}
+
+	revoked := NewHTTPRepository(WithBaseURL(baseURL), WithOAuth2ClientCredentials(tokenSrv.URL, "client", "s3cr3t"))
+	if _, err := revoked.Fetch(context.Background(), _fakeStubID); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("OAuth2_Unauthorized retried once got: %v, want: %v", err, ErrUnauthorized)
+	}
+}
+
+func TestOAuth2_Concurrent(t *testing.T) {
+	tokens := &mockTokenEndpoint{expiresIn: 3600, delay: 20 * time.Millisecond}
+	tokenSrv := httptest.NewServer(tokens)
+	defer tokenSrv.Close()
+	ts := newTokenSource(http.DefaultClient, tokenSrv.URL, "client", "s3cr3t", nil)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if token, err := ts.Token(context.Background()); err != nil || token != "token-1" {
+				t.Errorf("OAuth2_Concurrent got: %s, %v, want: token-1", token, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := atomic.LoadInt32(&tokens.issued); got != 1 {
+		t.Errorf("OAuth2_Concurrent token requests got: %d, want: 1", got)
+	}
+}
+
+func TestOAuth2_Error(t *testing.T) {
+	cases := []struct {
+		name    string
+		handler http.HandlerFunc
+		want    string
+	}{
+		{"invalid client", func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":"invalid_client","error_description":"unknown client"}`))
+		}, "oauth2: token response: status_code: 401: invalid_client: unknown client"},
+		{"decode", func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write([]byte(`token`))
+		}, "oauth2: token response decode: invalid character 'o' in literal true (expecting 'r')"},
+		{"empty token", func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write([]byte(`{"token_type":"bearer"}`))
+		}, "oauth2: token response: access_token is empty"},
+	}
+
+	for _, tt := range cases {
+		srv := httptest.NewServer(tt.handler)
+		ts := newTokenSource(http.DefaultClient, srv.URL, "client", "s3cr3t", nil)
+		if _, got := ts.Token(context.Background()); got == nil || got.Error() != tt.want {
+			t.Errorf("OAuth2_Error(%v) got: %v, want: %v", tt.name, got, tt.want)
+		}
+		srv.Close()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	ts := newTokenSource(http.DefaultClient, "http://localhost", "client", "s3cr3t", nil)
+	ts.sem <- struct{}{}
+	if _, got := ts.Token(ctx); !errors.Is(got, context.Canceled) {
+		t.Errorf("OAuth2_Error(context) got: %v, want: %v", got, context.Canceled)
+	}
+}
+
+func (m *mockTokenEndpoint) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	id, secret, ok := r.BasicAuth()
+	if !ok || id != "client" || secret != "s3cr3t" || r.PostFormValue("grant_type") != "client_credentials" {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"error":"invalid_client"}`))
+		return
+	}
+	time.Sleep(m.delay)
+
+	n := atomic.AddInt32(&m.issued, 1)
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = fmt.Fprintf(w, `{"access_token":"token-%d","token_type":"bearer","expires_in":%d}`, n, m.expiresIn)
+}
+
+// requireBearer returns a handler acting as account-api that answers 401 unless valid accepts the bearer token.
+func requireBearer(valid func(token string) bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !valid(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")) {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error_message":"invalid token"}`))
+			return
+		}
+		status := http.StatusOK
+		if r.Method == _post {
+			status = http.StatusCreated
+		}
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(`{"data":{"id":"ad27e265-9605-4b4b-a0e5-3003ea9cc4d2"}}`))
+	})
+}
@@ -0,0 +1,183 @@
+package account
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type (
+	// rateLimiter is a token bucket that refills at rate tokens per second up to burst tokens. Each request takes a
+	// token, waiting for one when the bucket is empty.
+	//
+	// It pauses when account-api asks to slow down, either through Retry-After or through X-RateLimit-Remaining: 0
+	// and X-RateLimit-Reset headers.
+	rateLimiter struct {
+		Requester
+		rate  float64
+		burst float64
+		now   func() time.Time
+		sleep func(ctx context.Context, d time.Duration) error
+
+		mu          sync.Mutex
+		tokens      float64
+		last        time.Time
+		pausedUntil time.Time
+	}
+	// concurrencyLimiter bounds the number of requests in flight. A request is in flight until its response body is
+	// closed.
+	concurrencyLimiter struct {
+		Requester
+		sem chan struct{}
+	}
+	releaseBody struct {
+		io.ReadCloser
+		once    sync.Once
+		release func()
+	}
+)
+
+const (
+	_rateLimitRemainingHeader = "X-RateLimit-Remaining"
+	_rateLimitResetHeader     = "X-RateLimit-Reset"
+	// _epochThreshold tells apart X-RateLimit-Reset values given as Unix time from the ones given in seconds from now.
+	_epochThreshold = 1000000000
+)
+
+func newRateLimiter(next Requester, rate float64, burst int) *rateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &rateLimiter{
+		Requester: next,
+		rate:      rate,
+		burst:     float64(burst),
+		tokens:    float64(burst),
+		now:       time.Now,
+		sleep:     sleep,
+	}
+}
+
+func rateLimitMiddleware(rate float64, burst int) Middleware {
+	return func(next Requester) Requester {
+		return newRateLimiter(next, rate, burst)
+	}
+}
+
+func (l *rateLimiter) Do(req *http.Request) (*http.Response, error) {
+	if err := l.wait(req.Context()); err != nil {
+		return nil, err
+	}
+
+	resp, err := l.Requester.Do(req)
+	if err == nil {
+		l.adapt(resp)
+	}
+
+	return resp, err
+}
+
+// wait blocks until a token is available or ctx is done.
+func (l *rateLimiter) wait(ctx context.Context) error {
+	for {
+		d, ok := l.take()
+		if ok {
+			return nil
+		}
+		if err := l.sleep(ctx, d); err != nil {
+			return err
+		}
+	}
+}
+
+// take takes a token if there is one available, otherwise it returns how long to wait before trying again.
+func (l *rateLimiter) take() (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if now.Before(l.pausedUntil) {
+		return l.pausedUntil.Sub(now), false
+	}
+	if !l.last.IsZero() {
+		l.tokens += now.Sub(l.last).Seconds() * l.rate
+		if l.tokens > l.burst {
+			l.tokens = l.burst
+		}
+	}
+	l.last = now
+
+	if l.tokens >= 1 {
+		l.tokens--
+		return 0, true
+	}
+
+	return time.Duration((1 - l.tokens) / l.rate * float64(time.Second)), false
+}
+
+// adapt pauses the limiter until the time account-api asks for, if any.
+func (l *rateLimiter) adapt(resp *http.Response) {
+	var until time.Time
+	if d, ok := retryAfter(resp); ok && (resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable) {
+		until = l.now().Add(d)
+	} else if resp.Header.Get(_rateLimitRemainingHeader) == "0" {
+		reset, err := strconv.ParseInt(resp.Header.Get(_rateLimitResetHeader), 10, 64)
+		if err != nil {
+			return
+		}
+		if reset >= _epochThreshold {
+			until = time.Unix(reset, 0)
+		} else {
+			until = l.now().Add(time.Duration(reset) * time.Second)
+		}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if until.After(l.pausedUntil) {
+		l.pausedUntil = until
+		l.tokens = 0
+	}
+}
+
+func newConcurrencyLimiter(next Requester, maxInFlight int) concurrencyLimiter {
+	return concurrencyLimiter{
+		Requester: next,
+		sem:       make(chan struct{}, maxInFlight),
+	}
+}
+
+func maxInFlightMiddleware(n int) Middleware {
+	return func(next Requester) Requester {
+		return newConcurrencyLimiter(next, n)
+	}
+}
+
+func (l concurrencyLimiter) Do(req *http.Request) (*http.Response, error) {
+	select {
+	case l.sem <- struct{}{}:
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	}
+	release := func() { <-l.sem }
+
+	resp, err := l.Requester.Do(req)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	resp.Body = &releaseBody{ReadCloser: resp.Body, release: release}
+
+	return resp, nil
+}
+
+func (b *releaseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+
+	return err
+}
@@ -0,0 +1,170 @@
+package account
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type mockBlockingRequester struct {
+	entered chan struct{}
+}
+
+func TestRateLimiter_Take(t *testing.T) {
+	now := time.Date(2021, 7, 1, 12, 0, 0, 0, time.UTC)
+	l := newRateLimiter(nil, 2, 2)
+	l.now = func() time.Time { return now }
+	cases := []struct {
+		name    string
+		advance time.Duration
+		ok      bool
+		wait    time.Duration
+	}{
+		{"burst 1", 0, true, 0},
+		{"burst 2", 0, true, 0},
+		{"empty", 0, false, 500 * time.Millisecond},
+		{"half refilled", 250 * time.Millisecond, false, 250 * time.Millisecond},
+		{"refilled", 250 * time.Millisecond, true, 0},
+		{"capped at burst", time.Hour, true, 0},
+		{"capped at burst 2", 0, true, 0},
+		{"capped at burst empty", 0, false, 500 * time.Millisecond},
+	}
+
+	for _, tt := range cases {
+		now = now.Add(tt.advance)
+		if wait, ok := l.take(); ok != tt.ok || wait != tt.wait {
+			t.Errorf("RateLimiter_Take(%v) got: %v, %v, want: %v, %v", tt.name, wait, ok, tt.wait, tt.ok)
+		}
+	}
+}
+
+func TestRateLimiter_Adapt(t *testing.T) {
+	now := time.Date(2021, 7, 1, 12, 0, 0, 0, time.UTC)
+	cases := []struct {
+		name   string
+		status int
+		header map[string]string
+		want   time.Duration
+	}{
+		{"retry after", 429, map[string]string{"Retry-After": "3"}, 3 * time.Second},
+		{"retry after unavailable", 503, map[string]string{"Retry-After": "4"}, 4 * time.Second},
+		{"retry after ignored", 200, map[string]string{"Retry-After": "3"}, 0},
+		{"reset in seconds", 200, map[string]string{_rateLimitRemainingHeader: "0", _rateLimitResetHeader: "5"}, 5 * time.Second},
+		{"reset in unix time", 200, map[string]string{_rateLimitRemainingHeader: "0", _rateLimitResetHeader: strconv.FormatInt(now.Add(6*time.Second).Unix(), 10)}, 6 * time.Second},
+		{"remaining", 200, map[string]string{_rateLimitRemainingHeader: "1", _rateLimitResetHeader: "5"}, 0},
+		{"invalid reset", 200, map[string]string{_rateLimitRemainingHeader: "0", _rateLimitResetHeader: "soon"}, 0},
+	}
+
+	for _, tt := range cases {
+		l := newRateLimiter(nil, 10, 10)
+		l.now = func() time.Time { return now }
+		header := http.Header{}
+		for k, v := range tt.header {
+			header.Set(k, v)
+		}
+		l.adapt(&http.Response{StatusCode: tt.status, Header: header})
+
+		wait, ok := l.take()
+		if ok != (tt.want == 0) || wait != tt.want {
+			t.Errorf("RateLimiter_Adapt(%v) got: %v, %v, want: %v", tt.name, wait, ok, tt.want)
+		}
+	}
+}
+
+func TestRateLimiter_Wait(t *testing.T) {
+	next := &mockScriptedRequester{responses: []mockResponse{{status: 200}}}
+	l := newRateLimiter(next, 1, 1)
+	var slept []time.Duration
+	l.sleep = func(_ context.Context, d time.Duration) error {
+		slept = append(slept, d)
+		l.tokens = 1
+		return nil
+	}
+	req, _ := http.NewRequest(_get, "http://localhost", nil)
+
+	for i := 0; i < 2; i++ {
+		if _, err := l.Do(req); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(slept) != 1 || len(next.methods) != 2 {
+		t.Errorf("RateLimiter_Wait got: %v sleeps, %d requests, want: 1 sleep, 2 requests", slept, len(next.methods))
+	}
+}
+
+func TestRateLimiter_Context(t *testing.T) {
+	repo := NewHTTPRepository(WithRateLimit(0.001, 1))
+	repo.client = httpclient{
+		errCtx:       "http_client",
+		buildRequest: http.NewRequestWithContext,
+		Requester:    newRateLimiter(&mockScriptedRequester{responses: []mockResponse{{status: 200}}}, 0.001, 1),
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, _ = repo.Health(ctx)
+	if _, err := repo.Health(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("RateLimiter_Context got: %v, want: %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestConcurrencyLimiter(t *testing.T) {
+	next := mockBlockingRequester{entered: make(chan struct{}, 3)}
+	l := newConcurrencyLimiter(next, 2)
+	req, _ := http.NewRequest(_get, "http://localhost", nil)
+
+	first, _ := l.Do(req)
+	_, _ = l.Do(req)
+	done := make(chan struct{})
+	go func() {
+		resp, _ := l.Do(req)
+		resp.Body.Close()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("ConcurrencyLimiter third request got: sent, want: waiting")
+	case <-time.After(20 * time.Millisecond):
+	}
+	first.Body.Close()
+	first.Body.Close()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("ConcurrencyLimiter third request got: waiting, want: sent")
+	}
+}
+
+func TestConcurrencyLimiter_Context(t *testing.T) {
+	l := newConcurrencyLimiter(mockBlockingRequester{entered: make(chan struct{}, 1)}, 1)
+	req, _ := http.NewRequest(_get, "http://localhost", nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, _ = l.Do(req)
+	if _, err := l.Do(req.WithContext(ctx)); !errors.Is(err, context.Canceled) {
+		t.Errorf("ConcurrencyLimiter_Context got: %v, want: %v", err, context.Canceled)
+	}
+}
+
+func TestConcurrencyLimiter_Error(t *testing.T) {
+	l := newConcurrencyLimiter(mockRequesterError{}, 1)
+	req, _ := http.NewRequest(_get, "http://localhost", nil)
+
+	for i := 0; i < 2; i++ {
+		if _, err := l.Do(req); err == nil || err.Error() != "error on do" {
+			t.Errorf("ConcurrencyLimiter_Error got: %v, want: error on do", err)
+		}
+	}
+}
+
+func (m mockBlockingRequester) Do(_ *http.Request) (*http.Response, error) {
+	m.entered <- struct{}{}
+	return &http.Response{StatusCode: 200, Body: mockCloser{nil}}, nil
+}
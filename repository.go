@@ -2,12 +2,20 @@ package account
 
 import (
 	"bytes"
+	"context"
+	"crypto/tls"
 	"encoding/json"
-	"fmt"
 	"io"
+	"log/slog"
+	"net"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type (
@@ -15,16 +23,69 @@ type (
 		apply(*httpOptions)
 	}
 	httpOptions struct {
-		addr string
-		port string
+		addr       string
+		port       string
+		baseURL    *url.URL
+		baseURLErr error
+		httpClient *http.Client
+		timeout    time.Duration
+		tlsConfig  *tls.Config
+		clientCert *certificateFiles
+		retry      *RetryPolicy
+		signature  *HTTPSignature
+		oauth2     *oauth2Credentials
+		rateLimit  *rateLimit
+		inFlight   int
+		breaker    *CircuitBreaker
+		middleware []Middleware
+		logger     *slog.Logger
+		log        LogOptions
+		cassette   *Cassette
+
+		tracerProvider trace.TracerProvider
+		metrics        Metrics
 	}
-	addrOption string
-	portOption string
+	rateLimit struct {
+		rate  float64
+		burst int
+	}
+	oauth2Credentials struct {
+		tokenURL string
+		clientID string
+		secret   string
+		scopes   []string
+	}
+	// baseURLConfig holds the base URL given to WithBaseURL, or why it cannot be used.
+	baseURLConfig struct {
+		url url.URL
+		err error
+	}
+	certificateFiles struct {
+		cert string
+		key  string
+		ca   string
+	}
+	addrOption              string
+	portOption              string
+	baseURLOption           baseURLConfig
+	httpClientOption        struct{ *http.Client }
+	timeoutOption           time.Duration
+	tlsConfigOption         struct{ *tls.Config }
+	clientCertificateOption certificateFiles
+	retryPolicyOption       RetryPolicy
+	httpSignatureOption     HTTPSignature
+	oauth2Option            oauth2Credentials
+	rateLimitOption         rateLimit
+	maxInFlightOption       int
+	circuitBreakerOption    CircuitBreaker
+	middlewareOption        []Middleware
+	loggerOption            struct{ *slog.Logger }
+	bodyLoggingOption       []string
 )
 
 type (
 	client interface {
-		request(method method, url string, body io.Reader) (*http.Response, error)
+		request(ctx context.Context, method method, url string, body io.Reader) (*http.Response, error)
 	}
 	marshal        func(v interface{}) ([]byte, error)
 	decode         func(d *json.Decoder, v interface{}) error
@@ -33,25 +94,28 @@ type (
 		client
 		decode
 
-		addr     string
-		port     string
+		baseURL  url.URL
 		errCtx   string
 		contType string
+		metrics  Metrics
 	}
 )
 
 type (
-	payload struct {
-		Data *data `json:"data"`
-	}
-	data struct {
-		Attributes     *attributes `json:"attributes,omitempty"`
-		ID             string      `json:"ID,omitempty"`
-		OrganisationID string      `json:"organisation_id,omitempty"`
-		Type           string      `json:"type,omitempty"`
-		Version        *int64      `json:"version,omitempty"`
+	// AccountData is an account resource as exchanged with account-api, e.g. in the body of create requests and of
+	// every successful response. Repository implementations receive and return accounts in this form.
+	//
+	// See: https://api-docs.form3.tech/api.html#organisation-accounts-resource
+	AccountData struct {
+		Attributes     *AccountAttributes `json:"attributes,omitempty"`
+		ID             string             `json:"ID,omitempty"`
+		OrganisationID string             `json:"organisation_id,omitempty"`
+		Type           string             `json:"type,omitempty"`
+		Version        *int64             `json:"version,omitempty"`
 	}
-	attributes struct {
+	// AccountAttributes holds the attributes of AccountData. In an update, nil attributes are left unchanged and
+	// non-nil ones are sent even when empty, so Name: []string{} or SecondaryIdentification: new(string) clears them.
+	AccountAttributes struct {
 		Classification          *string  `json:"account_classification,omitempty"`
 		MatchingOptOut          *bool    `json:"account_matching_opt_out,omitempty"`
 		Number                  string   `json:"account_number,omitempty"`
@@ -64,10 +128,27 @@ type (
 		Iban                    string   `json:"iban,omitempty"`
 		JointAccount            *bool    `json:"joint_account,omitempty"`
 		Name                    []string `json:"name,omitempty"`
-		SecondaryIdentification string   `json:"secondary_identification,omitempty"`
+		SecondaryIdentification *string  `json:"secondary_identification,omitempty"`
 		Status                  *string  `json:"status,omitempty"`
 		Switched                *bool    `json:"switched,omitempty"`
 	}
+	// AccountList is a page of accounts as returned by account-api when listing accounts.
+	AccountList struct {
+		Data  []AccountData `json:"data"`
+		Links *ListLinks    `json:"links,omitempty"`
+	}
+	// ListLinks holds the links to the other pages of an AccountList. Service follows Next to build Page.Next, so
+	// only its query matters.
+	ListLinks struct {
+		First string `json:"first,omitempty"`
+		Last  string `json:"last,omitempty"`
+		Next  string `json:"next,omitempty"`
+		Prev  string `json:"prev,omitempty"`
+		Self  string `json:"self,omitempty"`
+	}
+	payload struct {
+		Data *AccountData `json:"data"`
+	}
 )
 
 const (
@@ -75,6 +156,11 @@ const (
 	_defaultHTTPPort    = "8080"
 )
 
+var (
+	_accountsPath = []string{"v1", "organisation", "accounts"}
+	_healthPath   = []string{"v1", "health"}
+)
+
 // NewHTTPRepository instantiates a httpRepository based on httpOption(s) passed as arguments. If no argument is passed
 // the defaults will be used.
 //
@@ -93,12 +179,12 @@ func NewHTTPRepository(opts ...httpOption) httpRepository {
 	}
 
 	return httpRepository{
-		addr:     options.addr,
-		port:     options.port,
+		baseURL:  options.url(),
+		metrics:  options.metrics,
 		errCtx:   "http_repository",
 		contType: "application/json",
 		marshal:  json.Marshal,
-		client:   newHTTPClient(),
+		client:   newHTTPClient(options),
 		decode:   func(d *json.Decoder, v interface{}) error { return d.Decode(v) },
 	}
 }
@@ -119,10 +205,44 @@ func WithPort(port string) httpOption {
 	return portOption(port)
 }
 
-func (r httpRepository) create(acc data) (*data, error) {
-	const urlBase = "http://%s:%s/v1/organisation/accounts"
+// WithBaseURL attaches the base URL of account-api to HTTP client. It supersedes WithAddr and WithPort, allowing
+// other schemes than http and a path prefix, e.g. https://gateway.example.com/account-api, which is prepended to every
+// account-api path.
+//
+// Example:
+//
+//	baseURL, err := url.Parse("https://gateway.example.com/account-api")
+//	repository := NewHTTPRepository(acc.WithBaseURL(baseURL))
+//
+// If u is nil, e.g. because the error of url.Parse was ignored, every request fails.
+func WithBaseURL(u *url.URL) httpOption {
+	if u == nil {
+		return baseURLOption{err: errors.New("base URL is nil")}
+	}
+
+	return baseURLOption{url: *u}
+}
+
+// WithBaseURLString is like WithBaseURL but parses the base URL from raw, which must be an absolute URL, e.g.
+// https://gateway.example.com/account-api. If it is not, every request fails with the parse error.
+func WithBaseURLString(raw string) httpOption {
+	u, err := url.Parse(raw)
+	if err == nil && (u.Scheme == "" || u.Host == "") {
+		err = errors.Errorf("base URL is not absolute: %s", raw)
+	}
+	if err != nil {
+		return baseURLOption{err: err}
+	}
+
+	return baseURLOption{url: *u}
+}
+
+func (r httpRepository) Create(ctx context.Context, acc AccountData) (ret *AccountData, err error) {
+	done := r.track(OperationCreate)
+	defer func() { done(err) }()
+
 	wrapErr := func(err error, msg string) error {
-		return errors.Wrapf(err, "%s#create() %s", r.errCtx, msg)
+		return errors.Wrapf(err, "%s#Create() %s", r.errCtx, msg)
 	}
 
 	data, err := r.marshal(payload{Data: &acc})
@@ -130,33 +250,52 @@ func (r httpRepository) create(acc data) (*data, error) {
 		return nil, wrapErr(err, "marshal")
 	}
 
-	url := fmt.Sprintf(urlBase, r.addr, r.port)
-	resp, err := r.request(_post, url, bytes.NewBuffer(data))
+	ctx, attempts := withAttempts(ctx)
+	resp, err := r.request(ctx, _post, r.endpoint(nil, _accountsPath...), bytes.NewBuffer(data))
 	if err != nil {
 		return nil, wrapErr(err, "request")
 	}
-	defer resp.Body.Close()
+	ret, err = r.handleCreateResp(resp, acc.ID)
+	// Closed before fetching as the body holds the in-flight slot, if any, the fetch would wait for.
+	resp.Body.Close()
+	if *attempts > 1 && errors.Is(err, ErrConflict) {
+		// A previous attempt has created the account before failing, so the conflict is with ourselves.
+		return r.Fetch(ctx, acc.ID)
+	}
 
-	return r.handleCreateResp(resp)
+	return ret, err
 }
 
-func (r httpRepository) handleCreateResp(resp *http.Response) (*data, error) {
-	const (
-		success     = 201
-		clientError = 400
-	)
+// MarshalJSON encodes AccountAttributes as omitempty does, but for AlternativeNames and Name which are encoded as
+// empty arrays when they are empty but not nil.
+func (a AccountAttributes) MarshalJSON() ([]byte, error) {
+	type plain AccountAttributes
+	nonNil := func(s []string) *[]string {
+		if s == nil {
+			return nil
+		}
+		return &s
+	}
+
+	return json.Marshal(struct {
+		plain
+		AlternativeNames *[]string `json:"alternative_names,omitempty"`
+		Name             *[]string `json:"name,omitempty"`
+	}{plain(a), nonNil(a.AlternativeNames), nonNil(a.Name)})
+}
+
+func (r httpRepository) handleCreateResp(resp *http.Response, id string) (*AccountData, error) {
+	const success = 201
 
 	switch resp.StatusCode {
 	case success:
 		return r.parseSuccess(resp.Body)
-	case clientError:
-		return r.parseClientError(resp.Body)
 	default:
-		return nil, errors.New(fmt.Sprintf("%s#handleCreateResp() status_code_verification: != (201|400)", r.errCtx))
+		return nil, r.parseError(resp, OperationCreate, id)
 	}
 }
 
-func (r httpRepository) parseSuccess(body io.ReadCloser) (*data, error) {
+func (r httpRepository) parseSuccess(body io.ReadCloser) (*AccountData, error) {
 	var ret payload
 	dec := json.NewDecoder(body)
 	if err := r.decode(dec, &ret); err != nil {
@@ -166,67 +305,313 @@ func (r httpRepository) parseSuccess(body io.ReadCloser) (*data, error) {
 	return ret.Data, nil
 }
 
-func (r httpRepository) parseClientError(body io.ReadCloser) (*data, error) {
-	type clientError struct {
-		Message string `json:"error_message"`
-	}
+func (r httpRepository) parseError(resp *http.Response, op Operation, id string) error {
+	return errors.Wrapf(newAPIError(resp, op, id), "%s#parseError() status_code_verification", r.errCtx)
+}
 
-	var cr clientError
-	dec := json.NewDecoder(body)
-	if err := r.decode(dec, &cr); err != nil {
-		return nil, errors.Wrapf(err, "%s#parseClientError() decode", r.errCtx)
+func (r httpRepository) Fetch(ctx context.Context, id string) (ret *AccountData, err error) {
+	done := r.track(OperationFetch)
+	defer func() { done(err) }()
+
+	resp, err := r.request(ctx, _get, r.endpoint(nil, append(_accountsPath, id)...), nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s#Fetch() request", r.errCtx)
 	}
+	defer resp.Body.Close()
 
-	return nil, errors.New(cr.Message)
+	return r.handleFetchResp(resp, id)
 }
 
-func (r httpRepository) fetch(id string) (*data, error) {
-	resp, err := r.request(_get, fmt.Sprintf("http://%s:%s/v1/organisation/accounts/%s", r.addr, r.port, id), nil)
+func (r httpRepository) Delete(ctx context.Context, id string, version int64) (err error) {
+	done := r.track(OperationDelete)
+	defer func() { done(err) }()
+
+	query := url.Values{"version": []string{strconv.FormatInt(version, 10)}}
+	resp, err := r.request(ctx, _delete, r.endpoint(query, append(_accountsPath, id)...), nil)
 	if err != nil {
-		return nil, errors.Wrapf(err, "%s#fetch() request", r.errCtx)
+		return errors.Wrapf(err, "%s#Delete() request", r.errCtx)
 	}
 	defer resp.Body.Close()
 
-	return r.handleFetchResp(resp)
+	return r.handleDeleteResp(resp, id, version)
 }
 
-func (r httpRepository) delete(id string, version int64) error {
-	resp, err := r.request(_delete, fmt.Sprintf("http://%s:%s/v1/organisation/accounts/%s?version=%v", r.addr, r.port, id, version), nil)
+func (r httpRepository) handleDeleteResp(resp *http.Response, id string, version int64) error {
+	const (
+		success  = 204
+		conflict = 409
+	)
+
+	switch resp.StatusCode {
+	case success:
+		return nil
+	case conflict:
+		return errors.Wrapf(&VersionConflictError{
+			ID:      id,
+			Version: version,
+			Err:     newAPIError(resp, OperationDelete, id),
+		}, "%s#handleDeleteResp() status_code_verification", r.errCtx)
+	default:
+		return r.parseError(resp, OperationDelete, id)
+	}
+}
+
+func (r httpRepository) handleFetchResp(resp *http.Response, id string) (*AccountData, error) {
+	const success = 200
+
+	switch resp.StatusCode {
+	case success:
+		return r.parseSuccess(resp.Body)
+	default:
+		return nil, r.parseError(resp, OperationFetch, id)
+	}
+}
+
+func (r httpRepository) Update(ctx context.Context, acc AccountData) (ret *AccountData, err error) {
+	done := r.track(OperationUpdate)
+	defer func() { done(err) }()
+
+	wrapErr := func(err error, msg string) error {
+		return errors.Wrapf(err, "%s#Update() %s", r.errCtx, msg)
+	}
+
+	data, err := r.marshal(payload{Data: &acc})
+	if err != nil {
+		return nil, wrapErr(err, "marshal")
+	}
+
+	resp, err := r.request(ctx, _patch, r.endpoint(nil, append(_accountsPath, acc.ID)...), bytes.NewBuffer(data))
 	if err != nil {
-		return errors.Wrapf(err, "%s#delete() request", r.errCtx)
+		return nil, wrapErr(err, "request")
 	}
 	defer resp.Body.Close()
 
-	return nil
+	return r.handleUpdateResp(resp, acc)
 }
 
-func (r httpRepository) handleFetchResp(resp *http.Response) (*data, error) {
+func (r httpRepository) handleUpdateResp(resp *http.Response, acc AccountData) (*AccountData, error) {
 	const (
-		success     = 200
-		clientError = 400
-		notFound    = 404
+		success  = 200
+		conflict = 409
 	)
 
 	switch resp.StatusCode {
 	case success:
 		return r.parseSuccess(resp.Body)
-	case clientError:
-		return r.parseClientError(resp.Body)
-	case notFound:
-		return nil, nil
+	case conflict:
+		var version int64
+		if acc.Version != nil {
+			version = *acc.Version
+		}
+		return nil, errors.Wrapf(&VersionConflictError{
+			ID:      acc.ID,
+			Version: version,
+			Err:     newAPIError(resp, OperationUpdate, acc.ID),
+		}, "%s#handleUpdateResp() status_code_verification", r.errCtx)
+	default:
+		return nil, r.parseError(resp, OperationUpdate, acc.ID)
+	}
+}
+
+func (r httpRepository) List(ctx context.Context, query url.Values) (ret *AccountList, err error) {
+	done := r.track(OperationList)
+	defer func() { done(err) }()
+
+	resp, err := r.request(ctx, _get, r.endpoint(query, _accountsPath...), nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s#List() request", r.errCtx)
+	}
+	defer resp.Body.Close()
+
+	return r.handleListResp(resp)
+}
+
+func (r httpRepository) handleListResp(resp *http.Response) (*AccountList, error) {
+	const success = 200
+
+	switch resp.StatusCode {
+	case success:
+		var ret AccountList
+		if err := r.decode(json.NewDecoder(resp.Body), &ret); err != nil {
+			return nil, errors.Wrapf(err, "%s#handleListResp() decode", r.errCtx)
+		}
+		return &ret, nil
 	default:
-		return nil, errors.New(fmt.Sprintf("%s#handleFetchResp() status_code_verification: != (200|40[04])", r.errCtx))
+		return nil, r.parseError(resp, OperationList, "")
 	}
 }
 
-func (r httpRepository) health() error {
-	resp, err := r.request(_get, fmt.Sprintf("http://%s:%s/v1/health", r.addr, r.port), nil)
+// Health checks the health of account-api. It always returns a HealthStatus, along with an error when account-api is
+// not up.
+func (r httpRepository) Health(ctx context.Context) (status *HealthStatus, err error) {
+	done := r.track(OperationHealth)
+	defer func() { done(err) }()
+
+	status = &HealthStatus{}
+	start := time.Now()
+	resp, err := r.request(ctx, _get, r.endpoint(nil, _healthPath...), nil)
+	status.ResponseTime = time.Since(start)
 	if err != nil {
-		return errors.Wrapf(err, "%s#health() request", r.errCtx)
+		return status, errors.Wrapf(err, "%s#Health() request", r.errCtx)
 	}
 	defer resp.Body.Close()
 
-	return nil
+	return status, r.handleHealthResp(resp, status)
+}
+
+func (r httpRepository) handleHealthResp(resp *http.Response, status *HealthStatus) error {
+	const success = 200
+
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, _maxErrorBodySize))
+	if err != nil {
+		return errors.Wrapf(err, "%s#handleHealthResp() read", r.errCtx)
+	}
+	status.StatusCode = resp.StatusCode
+	status.Details = raw
+
+	var body struct {
+		Status string `json:"status"`
+	}
+	_ = json.Unmarshal(raw, &body)
+	status.Status = body.Status
+
+	switch {
+	case resp.StatusCode != success:
+		resp.Body = io.NopCloser(bytes.NewReader(raw))
+		return r.parseError(resp, OperationHealth, "")
+	case body.Status != "" && body.Status != _healthUp:
+		return errors.Wrapf(ErrUnhealthy, "%s#handleHealthResp() status: %s", r.errCtx, body.Status)
+	default:
+		status.Up = true
+		return nil
+	}
+}
+
+// WithHTTPClient makes HTTP client send requests through c instead of a zero value http.Client. c is copied, so
+// WithTimeout, WithTLSConfig and WithClientCertificate never change it.
+func WithHTTPClient(c *http.Client) httpOption {
+	return httpClientOption{c}
+}
+
+// WithTimeout sets the time limit of each request made by HTTP client, including connection, redirects and reading
+// the response body. Zero means no timeout, which is the default.
+func WithTimeout(d time.Duration) httpOption {
+	return timeoutOption(d)
+}
+
+// WithTLSConfig sets the TLS configuration used to reach account-api over https. It requires the transport of the
+// HTTP client to be a *http.Transport, which is the default.
+func WithTLSConfig(c *tls.Config) httpOption {
+	return tlsConfigOption{c}
+}
+
+// WithClientCertificate enables mutual TLS authentication. certFile and keyFile are PEM encoded files holding the
+// client certificate and its private key. caFile is an optional PEM encoded file holding the certificate authorities
+// trusted to verify account-api, the system pool is used when it is empty.
+//
+// Files are loaded when the repository is instantiated. If loading fails, every request fails with the load error.
+func WithClientCertificate(certFile, keyFile, caFile string) httpOption {
+	return clientCertificateOption{cert: certFile, key: keyFile, ca: caFile}
+}
+
+// WithRetryPolicy enables automatic retries of failed account-api calls according to RetryPolicy. Zero valued
+// attributes of RetryPolicy take their documented defaults. Retries are disabled by default.
+func WithRetryPolicy(p RetryPolicy) httpOption {
+	return retryPolicyOption(p)
+}
+
+// WithHTTPSignature signs every request sent to account-api according to HTTPSignature. Each attempt of a retried
+// request is signed again.
+//
+// The private key is parsed when the repository is instantiated. If parsing fails, every request fails with the
+// parse error.
+//
+// Along with WithOAuth2ClientCredentials, the signature is sent in the Signature header whatever
+// HTTPSignature.SignatureHeader, so the bearer token does not overwrite it.
+func WithHTTPSignature(s HTTPSignature) httpOption {
+	return httpSignatureOption(s)
+}
+
+// WithOAuth2ClientCredentials authenticates every request sent to account-api with a bearer token obtained from
+// tokenURL through the OAuth2 client credentials grant. clientID and secret are sent with HTTP Basic authentication.
+//
+// Tokens are cached and refreshed shortly before they expire. When account-api answers 401 the token is refreshed
+// and the request is sent once more.
+//
+// See: https://datatracker.ietf.org/doc/html/rfc6749#section-4.4
+func WithOAuth2ClientCredentials(tokenURL, clientID, secret string, scopes ...string) httpOption {
+	return oauth2Option{tokenURL: tokenURL, clientID: clientID, secret: secret, scopes: scopes}
+}
+
+// WithRateLimit limits the requests sent to account-api to rate per second, allowing bursts of up to burst requests.
+// Requests wait for their turn, giving up when their context is done. Each attempt of a retried request counts.
+//
+// The limiter also slows down when account-api asks for it, pausing until the time given by Retry-After in 429 and
+// 503 responses, or by X-RateLimit-Reset when X-RateLimit-Remaining is 0. A rate lower or equal to zero disables
+// it, which is the default.
+func WithRateLimit(rate float64, burst int) httpOption {
+	return rateLimitOption{rate: rate, burst: burst}
+}
+
+// WithMaxInFlight limits the number of requests to account-api in flight at once to n. A request is in flight until
+// its response has been read. Requests wait for a free slot, giving up when their context is done. A value lower or
+// equal to zero disables it, which is the default.
+func WithMaxInFlight(n int) httpOption {
+	return maxInFlightOption(n)
+}
+
+// WithCircuitBreaker stops calling account-api while it is failing, according to CircuitBreaker. Calls fail fast with
+// CircuitOpenError instead of waiting for account-api to time out. It is disabled by default.
+func WithCircuitBreaker(b CircuitBreaker) httpOption {
+	return circuitBreakerOption(b)
+}
+
+// WithMiddleware wraps every request sent to account-api with middlewares, the first one being the outermost. They
+// run inside retries, the circuit breaker and the limiters, so each attempt goes through them, and outside HTTP
+// signature and OAuth2, so the headers they add can be signed. It can be given several times, the middlewares
+// are appended.
+func WithMiddleware(middlewares ...Middleware) httpOption {
+	return middlewareOption(middlewares)
+}
+
+// WithLogger logs every request sent to account-api to l, with its method, URL, status code, latency, attempt number
+// and request ID. The values of the list filters holding personal data, e.g. filter[iban], are masked. Nothing is
+// logged by default.
+//
+// See: LoggingMiddleware
+func WithLogger(l *slog.Logger) httpOption {
+	return loggerOption{l}
+}
+
+// WithBodyLogging makes WithLogger log request and response bodies too. The values of the JSON attributes named
+// redact are masked. When redact is empty the attributes holding personal data are masked: name, alternative_names,
+// iban, account_number and secondary_identification.
+func WithBodyLogging(redact ...string) httpOption {
+	return bodyLoggingOption(redact)
+}
+
+// url returns the base URL of account-api.
+func (o httpOptions) url() url.URL {
+	if o.baseURL != nil {
+		return *o.baseURL
+	}
+
+	return url.URL{Scheme: "http", Host: net.JoinHostPort(o.addr, o.port)}
+}
+
+// endpoint resolves the path segments, escaping each one, and the query against the base URL.
+func (r httpRepository) endpoint(query url.Values, segments ...string) string {
+	escaped := make([]string, len(segments))
+	for i, s := range segments {
+		escaped[i] = url.PathEscape(s)
+	}
+
+	u := r.baseURL
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/" + strings.Join(segments, "/")
+	u.RawPath = strings.TrimSuffix(r.baseURL.EscapedPath(), "/") + "/" + strings.Join(escaped, "/")
+	u.RawQuery = query.Encode()
+
+	return u.String()
 }
 
 func (a addrOption) apply(opts *httpOptions) {
@@ -235,3 +620,72 @@ func (a addrOption) apply(opts *httpOptions) {
 func (p portOption) apply(opts *httpOptions) {
 	opts.port = string(p)
 }
+func (b baseURLOption) apply(opts *httpOptions) {
+	if b.err != nil {
+		opts.baseURL, opts.baseURLErr = nil, b.err
+		return
+	}
+	u := b.url
+	opts.baseURL, opts.baseURLErr = &u, nil
+}
+
+func (h httpClientOption) apply(opts *httpOptions) {
+	opts.httpClient = h.Client
+}
+
+func (t timeoutOption) apply(opts *httpOptions) {
+	opts.timeout = time.Duration(t)
+}
+
+func (t tlsConfigOption) apply(opts *httpOptions) {
+	opts.tlsConfig = t.Config
+}
+
+func (c clientCertificateOption) apply(opts *httpOptions) {
+	files := certificateFiles(c)
+	opts.clientCert = &files
+}
+
+func (r retryPolicyOption) apply(opts *httpOptions) {
+	policy := RetryPolicy(r)
+	opts.retry = &policy
+}
+
+func (h httpSignatureOption) apply(opts *httpOptions) {
+	signature := HTTPSignature(h)
+	opts.signature = &signature
+}
+
+func (o oauth2Option) apply(opts *httpOptions) {
+	credentials := oauth2Credentials(o)
+	opts.oauth2 = &credentials
+}
+
+func (r rateLimitOption) apply(opts *httpOptions) {
+	limit := rateLimit(r)
+	opts.rateLimit = &limit
+}
+
+func (m maxInFlightOption) apply(opts *httpOptions) {
+	opts.inFlight = int(m)
+}
+
+func (c circuitBreakerOption) apply(opts *httpOptions) {
+	breaker := CircuitBreaker(c)
+	opts.breaker = &breaker
+}
+
+func (m middlewareOption) apply(opts *httpOptions) {
+	opts.middleware = append(opts.middleware, m...)
+}
+
+func (l loggerOption) apply(opts *httpOptions) {
+	opts.logger = l.Logger
+}
+
+func (b bodyLoggingOption) apply(opts *httpOptions) {
+	opts.log.Bodies = true
+	if len(b) > 0 {
+		opts.log.Redact = b
+	}
+}
@@ -2,14 +2,19 @@ package account
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"flag"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"net/url"
 	"reflect"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/pkg/errors"
@@ -25,6 +30,12 @@ type (
 	mockRequestCreate              struct{}
 	mockRequestNotFound            struct{}
 	mockRequestInternalServerError struct{}
+	mockRequestNoContent           struct{}
+	mockRequestConflict            struct{}
+	mockRequestStatus              struct {
+		status int
+		body   string
+	}
 )
 
 var (
@@ -32,6 +43,9 @@ var (
 	_fakeStubUUID, _ = uuid.Parse(_fakeStubID)
 	_itAddress       = flag.String("itaddr", "0.0.0.0", "address of account-api service")
 	_itPort          = "8080"
+	_itReadyTimeout  = time.Minute
+	_itReady         sync.Once
+	_itReadyErr      error
 )
 
 var (
@@ -86,8 +100,8 @@ var (
 )
 
 var (
-	_accountStub = data{
-		Attributes: &attributes{
+	_accountStub = AccountData{
+		Attributes: &AccountAttributes{
 			BankID:       "400300",
 			BankIDCode:   "GBDSC",
 			BaseCurrency: "GBP",
@@ -100,7 +114,7 @@ var (
 		Type:           "accounts",
 		Version:        &_versionStub,
 	}
-	_accountFailedStub = data{
+	_accountFailedStub = AccountData{
 		Type: "accounts",
 	}
 )
@@ -111,7 +125,7 @@ func TestRepositoryCreateIntegration(t *testing.T) {
 	account := _accountStub
 	repo := NewHTTPRepository(WithAddr(*_itAddress), WithPort(_itPort))
 
-	got, err := repo.create(account)
+	got, err := repo.Create(context.Background(), account)
 	if err != nil {
 		t.Fatal()
 	}
@@ -125,7 +139,7 @@ func TestRepositoryCreate_ErrorIntegration(t *testing.T) {
 	account := _accountFailedStub
 	repo := NewHTTPRepository(WithAddr(*_itAddress), WithPort(_itPort))
 
-	_, err := repo.create(account)
+	_, err := repo.Create(context.Background(), account)
 	if err == nil {
 		t.Fatal()
 	}
@@ -137,7 +151,7 @@ func TestHealthIntegration(t *testing.T) {
 	skipShort(t)
 	repo := NewHTTPRepository(WithAddr(*_itAddress), WithPort(_itPort))
 
-	if err := repo.health(); err != nil {
+	if _, err := repo.Health(context.Background()); err != nil {
 		t.Fail()
 	}
 }
@@ -160,7 +174,7 @@ func TestFetchIntegration(t *testing.T) {
 	addStub(t)
 	repo := NewHTTPRepository(WithAddr(*_itAddress), WithPort(_itPort))
 
-	if got, _ := repo.fetch(_fakeStubID); got != nil {
+	if got, _ := repo.Fetch(context.Background(), _fakeStubID); got != nil {
 		assertInterface("Attribute", got.Attributes, _accountStub.Attributes)
 		assertInterface("Version", got.Version, _accountStub.Version)
 		assertData("ID", got.ID, _accountStub.ID)
@@ -178,7 +192,7 @@ func TestDeleteIntegration(t *testing.T) {
 	addStub(t)
 	repo := NewHTTPRepository(WithAddr(*_itAddress), WithPort(_itPort))
 
-	if err := repo.delete(_fakeStubID, int64(0)); err != nil {
+	if err := repo.Delete(context.Background(), _fakeStubID, int64(0)); err != nil {
 		t.Fail()
 	}
 }
@@ -189,9 +203,9 @@ func TestFetch_NotFoundIntegration(t *testing.T) {
 	repo := NewHTTPRepository(WithAddr(*_itAddress), WithPort(_itPort))
 	nfID := "eed9954a-a58b-4f59-b44f-8d0592748d53"
 
-	got, err := repo.fetch(nfID)
+	got, err := repo.Fetch(context.Background(), nfID)
 
-	if got != nil || err != nil {
+	if got != nil || !errors.Is(err, ErrNotFound) {
 		t.Fail()
 	}
 }
@@ -201,7 +215,7 @@ func TestRepositoryFetch_ErrorIntegration(t *testing.T) {
 	repo := NewHTTPRepository(WithAddr(*_itAddress), WithPort(_itPort))
 	invalidID := "666"
 
-	_, err := repo.fetch(invalidID)
+	_, err := repo.Fetch(context.Background(), invalidID)
 	if err == nil {
 		t.Errorf("RepositoryFetch_ErrorIntegration in: %v, want: NOT ERROR", invalidID)
 	}
@@ -210,31 +224,42 @@ func TestRepositoryFetch_ErrorIntegration(t *testing.T) {
 }
 
 func TestHealth_Error(t *testing.T) {
-	error := "http_repository#health() request: error on request"
+	error := "http_repository#Health() request: error on request"
 
-	got := _repositoryWithRequestError.health()
+	_, got := _repositoryWithRequestError.Health(context.Background())
 
 	if got.Error() != error {
 		t.Errorf("RepositoryHealth_Error got: %v, want: %v", got, error)
 	}
 }
 
+func TestHealth_Unhealthy(t *testing.T) {
+	repo := httpRepository{errCtx: "http_repository", client: mockRequestStatus{status: 503, body: "maintenance"}}
+
+	_, got := repo.Health(context.Background())
+
+	var apiErr *APIError
+	if !errors.As(got, &apiErr) || apiErr.Operation != OperationHealth || !errors.Is(got, ErrServer) {
+		t.Errorf("RepositoryHealth_Unhealthy got: %v, want: %v", got, ErrServer)
+	}
+}
+
 func TestRepositoryCreate_Error(t *testing.T) {
 	cases := []struct {
 		name string
 		in   httpRepository
 		want error
 	}{
-		{"marshal", _repositoryWithMarshalError, errors.New("http_repository#create() marshal: error on marshal")},
-		{"post", _repositoryWithPostError, errors.New("http_repository#create() request: error on request")},
-		{"unsuccessfully status code", _repositoryWithUnsuccessfullyStatusCode, errors.New("http_repository#handleCreateResp() status_code_verification: != (201|400)")},
+		{"marshal", _repositoryWithMarshalError, errors.New("http_repository#Create() marshal: error on marshal")},
+		{"post", _repositoryWithPostError, errors.New("http_repository#Create() request: error on request")},
+		{"unsuccessfully status code", _repositoryWithUnsuccessfullyStatusCode, errors.New("http_repository#parseError() status_code_verification: account_api create: id: ad27e265-9605-4b4b-a0e5-3003ea9cc4d2: status_code: 500: mock")},
 		{"decode success error", _repositoryWithDecodeSuccessErrorCreate, errors.New("http_repository#parseSuccess() decode: error on decode success")},
-		{"decode badRequest error", _repositoryWithDecodeBadRequestError, errors.New("http_repository#parseClientError() decode: error on decode badRequest")},
+		{"bad request", _repositoryWithDecodeBadRequestError, errors.New("http_repository#parseError() status_code_verification: account_api create: id: ad27e265-9605-4b4b-a0e5-3003ea9cc4d2: status_code: 400: mock")},
 	}
 	acc := _accountStub
 
 	for _, tt := range cases {
-		_, got := tt.in.create(acc)
+		_, got := tt.in.Create(context.Background(), acc)
 		if got.Error() != tt.want.Error() {
 			t.Errorf("RepositoryCreate_Error(%v) got: %v, want: %v", tt.name, got, tt.want)
 		}
@@ -247,32 +272,253 @@ func TestRepositoryFetch_Error(t *testing.T) {
 		in   httpRepository
 		want error
 	}{
-		{"request", _repositoryWithRequestError, errors.New("http_repository#fetch() request: error on request")},
-		{"unsuccessfully status code", _repositoryWithUnsuccessfullyStatusCode, errors.New("http_repository#handleFetchResp() status_code_verification: != (200|40[04])")},
+		{"request", _repositoryWithRequestError, errors.New("http_repository#Fetch() request: error on request")},
+		{"unsuccessfully status code", _repositoryWithUnsuccessfullyStatusCode, errors.New("http_repository#parseError() status_code_verification: account_api fetch: id: ad27e265-9605-4b4b-a0e5-3003ea9cc4d2: status_code: 500: mock")},
 		{"decode success error", _repositoryWithDecodeSuccessErrorFetch, errors.New("http_repository#parseSuccess() decode: error on decode success")},
-		{"decode badRequest error", _repositoryWithDecodeBadRequestError, errors.New("http_repository#parseClientError() decode: error on decode badRequest")},
+		{"bad request", _repositoryWithDecodeBadRequestError, errors.New("http_repository#parseError() status_code_verification: account_api fetch: id: ad27e265-9605-4b4b-a0e5-3003ea9cc4d2: status_code: 400: mock")},
 	}
 	for _, tt := range cases {
-		_, got := tt.in.fetch(_fakeStubID)
+		_, got := tt.in.Fetch(context.Background(), _fakeStubID)
 		if got.Error() != tt.want.Error() {
 			t.Errorf("RepositoryFetch_Error(%v) got: %v, want: %v", tt.name, got, tt.want)
 		}
 	}
 }
 
+func TestRepositoryFetch_NotFound(t *testing.T) {
+	want := "http_repository#parseError() status_code_verification: account_api fetch: id: ad27e265-9605-4b4b-a0e5-3003ea9cc4d2: status_code: 404: mock"
+
+	got, err := _repositoryWithUnsuccessfullyStatusCodeCreate.Fetch(context.Background(), _fakeStubID)
+	if got != nil || !errors.Is(err, ErrNotFound) || err.Error() != want {
+		t.Errorf("RepositoryFetch_NotFound got: %v, want: %v", err, want)
+	}
+}
+
 func TestRepositoryDelete_Error(t *testing.T) {
-	want := "http_repository#delete() request: error on request"
+	want := "http_repository#Delete() request: error on request"
 
-	got := _repositoryWithRequestError.delete(_fakeStubID, int64(0))
+	got := _repositoryWithRequestError.Delete(context.Background(), _fakeStubID, int64(0))
 	if got.Error() != want {
 		t.Errorf("RepositoryDelete_Error got: %v, want: %v", got, want)
 	}
 }
 
+func TestRepositoryDelete_StatusCode(t *testing.T) {
+	cases := []struct {
+		name string
+		in   client
+		want error
+	}{
+		{"no content", mockRequestNoContent{}, nil},
+		{"not found", mockRequestNotFound{}, ErrNotFound},
+		{"conflict", mockRequestConflict{}, ErrVersionConflict},
+		{"internal server error", mockRequestInternalServerError{}, ErrServer},
+	}
+
+	for _, tt := range cases {
+		repo := httpRepository{errCtx: "http_repository", client: tt.in}
+		got := repo.Delete(context.Background(), _fakeStubID, int64(3))
+		if (got == nil) != (tt.want == nil) || (tt.want != nil && !errors.Is(got, tt.want)) {
+			t.Errorf("RepositoryDelete_StatusCode(%v) got: %v, want: %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRepositoryDelete_VersionConflict(t *testing.T) {
+	repo := httpRepository{errCtx: "http_repository", client: mockRequestConflict{}}
+	want := "http_repository#handleDeleteResp() status_code_verification: id: ad27e265-9605-4b4b-a0e5-3003ea9cc4d2: version: 3: version conflict: account_api delete: id: ad27e265-9605-4b4b-a0e5-3003ea9cc4d2: status_code: 409: mock"
+
+	got := repo.Delete(context.Background(), _fakeStubID, int64(3))
+
+	var vcErr *VersionConflictError
+	if !errors.As(got, &vcErr) || vcErr.Version != 3 || vcErr.ID != _fakeStubID || !errors.Is(got, ErrConflict) {
+		t.Errorf("RepositoryDelete_VersionConflict got: %v", got)
+	}
+	if got.Error() != want {
+		t.Errorf("RepositoryDelete_VersionConflict got: %v, want: %v", got, want)
+	}
+}
+
+func TestRepositoryUpdate(t *testing.T) {
+	repo := httpRepository{
+		errCtx:  "http_repository",
+		marshal: json.Marshal,
+		client:  mockRequestStatus{status: 200, body: `{"data":{"id":"ad27e265-9605-4b4b-a0e5-3003ea9cc4d2","version":1}}`},
+		decode:  func(d *json.Decoder, v interface{}) error { return d.Decode(v) },
+	}
+	version := int64(1)
+	want := &AccountData{ID: _fakeStubID, Version: &version}
+
+	got, err := repo.Update(context.Background(), _accountStub)
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("RepositoryUpdate got: %v, want: %v", got, want)
+	}
+}
+
+func TestRepositoryUpdate_Error(t *testing.T) {
+	cases := []struct {
+		name string
+		in   httpRepository
+		want error
+	}{
+		{"marshal", _repositoryWithMarshalError, errors.New("http_repository#Update() marshal: error on marshal")},
+		{"request", _repositoryWithPostError, errors.New("http_repository#Update() request: error on request")},
+		{"unsuccessfully status code", _repositoryWithUnsuccessfullyStatusCode, errors.New("http_repository#parseError() status_code_verification: account_api update: id: ad27e265-9605-4b4b-a0e5-3003ea9cc4d2: status_code: 500: mock")},
+		{"decode success error", _repositoryWithDecodeSuccessErrorFetch, errors.New("http_repository#parseSuccess() decode: error on decode success")},
+		{"conflict", httpRepository{errCtx: "http_repository", marshal: json.Marshal, client: mockRequestConflict{}}, errors.New("http_repository#handleUpdateResp() status_code_verification: id: ad27e265-9605-4b4b-a0e5-3003ea9cc4d2: version: 0: version conflict: account_api update: id: ad27e265-9605-4b4b-a0e5-3003ea9cc4d2: status_code: 409: mock")},
+	}
+
+	for _, tt := range cases {
+		_, got := tt.in.Update(context.Background(), _accountStub)
+		if got.Error() != tt.want.Error() {
+			t.Errorf("RepositoryUpdate_Error(%v) got: %v, want: %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRepositoryUpdate_VersionConflict(t *testing.T) {
+	repo := httpRepository{errCtx: "http_repository", marshal: json.Marshal, client: mockRequestConflict{}}
+
+	_, got := repo.Update(context.Background(), _accountStub)
+
+	var vcErr *VersionConflictError
+	if !errors.Is(got, ErrVersionConflict) || !errors.As(got, &vcErr) || vcErr.Version != _versionStub {
+		t.Errorf("RepositoryUpdate_VersionConflict got: %v", got)
+	}
+}
+
+func TestRepositoryList(t *testing.T) {
+	body := `{"data":[{"id":"ad27e265-9605-4b4b-a0e5-3003ea9cc4d2","type":"accounts"}],"links":{"next":"/v1/organisation/accounts?page%5Bnumber%5D=1"}}`
+	repo := httpRepository{
+		errCtx: "http_repository",
+		client: mockRequestStatus{status: 200, body: body},
+		decode: func(d *json.Decoder, v interface{}) error { return d.Decode(v) },
+	}
+	want := &AccountList{
+		Data:  []AccountData{{ID: _fakeStubID, Type: "accounts"}},
+		Links: &ListLinks{Next: "/v1/organisation/accounts?page%5Bnumber%5D=1"},
+	}
+
+	got, err := repo.List(context.Background(), url.Values{"page[size]": []string{"1"}})
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("RepositoryList got: %v, want: %v", got, want)
+	}
+}
+
+func TestRepositoryList_Error(t *testing.T) {
+	cases := []struct {
+		name string
+		in   httpRepository
+		want error
+	}{
+		{"request", _repositoryWithRequestError, errors.New("http_repository#List() request: error on request")},
+		{"unsuccessfully status code", _repositoryWithUnsuccessfullyStatusCode, errors.New("http_repository#parseError() status_code_verification: account_api list: id: : status_code: 500: mock")},
+		{"decode success error", _repositoryWithDecodeSuccessErrorFetch, errors.New("http_repository#handleListResp() decode: error on decode success")},
+	}
+	for _, tt := range cases {
+		_, got := tt.in.List(context.Background(), url.Values{})
+		if got.Error() != tt.want.Error() {
+			t.Errorf("RepositoryList_Error(%v) got: %v, want: %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRepositoryListIntegration(t *testing.T) {
+	skipShort(t)
+	addStub(t)
+	repo := NewHTTPRepository(WithAddr(*_itAddress), WithPort(_itPort))
+
+	got, err := repo.List(context.Background(), url.Values{"page[size]": []string{"100"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, d := range got.Data {
+		if d.ID == _fakeStubID {
+			return
+		}
+	}
+	t.Errorf("RepositoryListIntegration %s not listed", _fakeStubID)
+}
+
+func TestNewHTTPRepository_BaseURL(t *testing.T) {
+	gateway, _ := url.Parse("https://gateway.example.com/account-api/")
+	cases := []struct {
+		name string
+		in   []httpOption
+		want string
+	}{
+		{"defaults", nil, "http://0.0.0.0:8080/v1/health"},
+		{"addr and port", []httpOption{WithAddr("accountapi"), WithPort("9090")}, "http://accountapi:9090/v1/health"},
+		{"base url", []httpOption{WithBaseURL(gateway)}, "https://gateway.example.com/account-api/v1/health"},
+		{"base url supersedes addr and port", []httpOption{WithBaseURL(gateway), WithAddr("accountapi"), WithPort("9090")}, "https://gateway.example.com/account-api/v1/health"},
+		{"base url string", []httpOption{WithBaseURLString("https://gateway.example.com/account-api")}, "https://gateway.example.com/account-api/v1/health"},
+		{"last base url", []httpOption{WithBaseURLString("http://"), WithBaseURL(gateway)}, "https://gateway.example.com/account-api/v1/health"},
+	}
+
+	for _, tt := range cases {
+		if got := NewHTTPRepository(tt.in...).endpoint(nil, _healthPath...); got != tt.want {
+			t.Errorf("NewHTTPRepository_BaseURL(%v) got: %v, want: %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestNewHTTPRepository_BaseURLError(t *testing.T) {
+	cases := []struct {
+		name string
+		in   httpOption
+		want string
+	}{
+		{"nil", WithBaseURL(nil), "http_client#newHTTPClient() base URL: base URL is nil"},
+		{"malformed", WithBaseURLString("http://gateway example.com"), "http_client#newHTTPClient() base URL: parse "},
+		{"relative", WithBaseURLString("/account-api"), "http_client#newHTTPClient() base URL: base URL is not absolute: /account-api"},
+	}
+
+	for _, tt := range cases {
+		_, err := NewHTTPRepository(tt.in).Fetch(context.Background(), _fakeStubID)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("NewHTTPRepository_BaseURLError(%v) got: %v, want: %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestEndpoint(t *testing.T) {
+	prefixed, _ := url.Parse("https://gateway.example.com/account%20api")
+	cases := []struct {
+		name     string
+		baseURL  url.URL
+		query    url.Values
+		segments []string
+		want     string
+	}{
+		{"id", url.URL{Scheme: "http", Host: "localhost:8080"}, nil, append(_accountsPath, _fakeStubID), "http://localhost:8080/v1/organisation/accounts/" + _fakeStubID},
+		{"escaped id", url.URL{Scheme: "http", Host: "localhost:8080"}, nil, append(_accountsPath, "../health?x=1"), "http://localhost:8080/v1/organisation/accounts/..%2Fhealth%3Fx=1"},
+		{"escaped prefix", *prefixed, nil, _accountsPath, "https://gateway.example.com/account%20api/v1/organisation/accounts"},
+		{"query", url.URL{Scheme: "http", Host: "localhost:8080"}, url.Values{"filter[iban]": []string{"GB33 BUKB"}}, _accountsPath, "http://localhost:8080/v1/organisation/accounts?filter%5Biban%5D=GB33+BUKB"},
+	}
+
+	for _, tt := range cases {
+		repo := httpRepository{baseURL: tt.baseURL}
+		if got := repo.endpoint(tt.query, tt.segments...); got != tt.want {
+			t.Errorf("Endpoint(%v) got: %v, want: %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// skipShort skips integration tests in short mode. Otherwise it waits for account-api to be ready, once.
 func skipShort(t *testing.T) {
 	if testing.Short() {
 		t.Skip("Skipping integration test")
 	}
+
+	_itReady.Do(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), _itReadyTimeout)
+		defer cancel()
+		_itReadyErr = NewService(NewHTTPRepository(WithAddr(*_itAddress), WithPort(_itPort))).WaitReady(ctx, PollOptions{})
+	})
+	if _itReadyErr != nil {
+		t.Fatalf("account-api is not ready: %v", _itReadyErr)
+	}
 }
 
 func deleteStub(t *testing.T) {
@@ -322,41 +568,62 @@ func addStub(t *testing.T) {
 
 func (mockCloser) Close() error { return nil }
 
-func (r mockRequestError) request(_ method, _ string, _ io.Reader) (*http.Response, error) {
+func (r mockRequestError) request(_ context.Context, _ method, _ string, _ io.Reader) (*http.Response, error) {
 	return nil, errors.New("error on request")
 }
 
-func (r mockRequestBadRequest) request(_ method, _ string, _ io.Reader) (*http.Response, error) {
+func (r mockRequestBadRequest) request(_ context.Context, _ method, _ string, _ io.Reader) (*http.Response, error) {
 	return &http.Response{
 		StatusCode: 400,
 		Body:       mockCloser{bytes.NewBuffer(_mockedBytes)},
 	}, nil
 }
 
-func (r mockRequestOk) request(_ method, _ string, _ io.Reader) (*http.Response, error) {
+func (r mockRequestOk) request(_ context.Context, _ method, _ string, _ io.Reader) (*http.Response, error) {
 	return &http.Response{
 		StatusCode: 200,
 		Body:       mockCloser{bytes.NewBuffer(_mockedBytes)},
 	}, nil
 }
 
-func (r mockRequestCreate) request(_ method, _ string, _ io.Reader) (*http.Response, error) {
+func (r mockRequestCreate) request(_ context.Context, _ method, _ string, _ io.Reader) (*http.Response, error) {
 	return &http.Response{
 		StatusCode: 201,
 		Body:       mockCloser{bytes.NewBuffer(_mockedBytes)},
 	}, nil
 }
 
-func (r mockRequestNotFound) request(_ method, _ string, _ io.Reader) (*http.Response, error) {
+func (r mockRequestNotFound) request(_ context.Context, _ method, _ string, _ io.Reader) (*http.Response, error) {
 	return &http.Response{
 		StatusCode: 404,
 		Body:       mockCloser{bytes.NewBuffer(_mockedBytes)},
 	}, nil
 }
 
-func (r mockRequestInternalServerError) request(_ method, _ string, _ io.Reader) (*http.Response, error) {
+func (r mockRequestInternalServerError) request(_ context.Context, _ method, _ string, _ io.Reader) (*http.Response, error) {
 	return &http.Response{
 		StatusCode: 500,
 		Body:       mockCloser{bytes.NewBuffer(_mockedBytes)},
 	}, nil
 }
+
+func (r mockRequestNoContent) request(_ context.Context, _ method, _ string, _ io.Reader) (*http.Response, error) {
+	return &http.Response{
+		StatusCode: 204,
+		Body:       mockCloser{bytes.NewBuffer(nil)},
+	}, nil
+}
+
+func (r mockRequestConflict) request(_ context.Context, _ method, _ string, _ io.Reader) (*http.Response, error) {
+	return &http.Response{
+		StatusCode: 409,
+		Body:       mockCloser{bytes.NewBuffer(_mockedBytes)},
+	}, nil
+}
+
+func (r mockRequestStatus) request(_ context.Context, _ method, _ string, _ io.Reader) (*http.Response, error) {
+	return &http.Response{
+		StatusCode: r.status,
+		Body:       mockCloser{bytes.NewBufferString(r.body)},
+	}, nil
+}
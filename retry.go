@@ -0,0 +1,272 @@
+package account
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type (
+	// RetryPolicy configures how failed account-api calls are retried. Requests are retried when they fail before a
+	// response is received because of a transient network error, e.g. a connection reset or a timeout, or when the
+	// response status code is in RetryableStatus. Other errors, e.g. an invalid client certificate, signing key or
+	// OAuth2 credentials, are returned at once.
+	//
+	// Fetch, list, delete and health calls are retried. Create calls are only retried when RetryCreate is set, and
+	// update calls are never retried.
+	RetryPolicy struct {
+		// MaxAttempts is the maximum number of attempts, including the first one. Default is 3.
+		MaxAttempts int
+		// BaseDelay is the delay before the first retry. It doubles at each attempt. Default is 100ms.
+		BaseDelay time.Duration
+		// MaxDelay caps the delay between attempts. Default is 5s. It caps the delays asked by account-api through
+		// Retry-After too: when it asks for a longer one, the response is returned without retrying.
+		MaxDelay time.Duration
+		// Jitter is the fraction, between 0 and 1, of each delay that is randomised in order to spread retries of
+		// concurrent callers. Default is 0, no jitter.
+		Jitter float64
+		// RetryableStatus is the set of status codes that are retried. Default is 429, 500, 502, 503 and 504.
+		RetryableStatus []int
+		// RetryCreate enables retries of create calls. It is safe because account-api identifies accounts by the
+		// client supplied ID: when a retried create is answered with 409, the account created by a previous attempt
+		// is fetched and returned.
+		RetryCreate bool
+	}
+	retrier struct {
+		Requester
+		policy RetryPolicy
+		sleep  func(ctx context.Context, d time.Duration) error
+		rand   func() float64
+	}
+	attemptsKey struct{}
+	attemptKey  struct{}
+)
+
+const (
+	_defaultMaxAttempts = 3
+	_defaultBaseDelay   = 100 * time.Millisecond
+	_defaultMaxDelay    = 5 * time.Second
+)
+
+var _defaultRetryableStatus = []int{
+	http.StatusTooManyRequests,
+	http.StatusInternalServerError,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+func newRetrier(next Requester, policy RetryPolicy) retrier {
+	if policy.MaxAttempts == 0 {
+		policy.MaxAttempts = _defaultMaxAttempts
+	}
+	if policy.BaseDelay == 0 {
+		policy.BaseDelay = _defaultBaseDelay
+	}
+	if policy.MaxDelay == 0 {
+		policy.MaxDelay = _defaultMaxDelay
+	}
+	if policy.RetryableStatus == nil {
+		policy.RetryableStatus = _defaultRetryableStatus
+	}
+
+	return retrier{
+		Requester: next,
+		policy:    policy,
+		sleep:     sleep,
+		rand:      rand.Float64,
+	}
+}
+
+// RetryMiddleware retries failed requests according to RetryPolicy, as WithRetryPolicy does.
+func RetryMiddleware(p RetryPolicy) Middleware {
+	return func(next Requester) Requester {
+		return newRetrier(next, p)
+	}
+}
+
+func (r retrier) Do(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	if !r.retryable(req) {
+		recordAttempt(ctx, 1)
+		return r.Requester.Do(req)
+	}
+
+	for attempt := 1; ; attempt++ {
+		attemptReq, err := rewind(req, attempt)
+		if err != nil {
+			return nil, errors.Wrap(err, "retry: rewind body")
+		}
+		recordAttempt(ctx, attempt)
+		if attempt > 1 {
+			recordRetry(ctx)
+		}
+
+		resp, err := r.Requester.Do(attemptReq.WithContext(context.WithValue(ctx, attemptKey{}, attempt)))
+		if attempt >= r.policy.MaxAttempts || ctx.Err() != nil || !r.shouldRetry(resp, err) {
+			return resp, err
+		}
+
+		delay, ok := r.delay(attempt, resp)
+		if !ok {
+			return resp, err
+		}
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		if err := r.sleep(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (r retrier) retryable(req *http.Request) bool {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+
+	switch req.Method {
+	case _get, _delete:
+		return true
+	case _post:
+		return r.policy.RetryCreate
+	default:
+		return false
+	}
+}
+
+func (r retrier) shouldRetry(resp *http.Response, err error) bool {
+	if err != nil {
+		return transient(err)
+	}
+	for _, s := range r.policy.RetryableStatus {
+		if resp.StatusCode == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+// delay computes the exponential backoff of attempt, unless the response asks to wait for a given time through the
+// Retry-After header. It returns false when that time is over MaxDelay, in which case the request is not retried.
+func (r retrier) delay(attempt int, resp *http.Response) (time.Duration, bool) {
+	if d, ok := retryAfter(resp); ok {
+		return d, d <= r.policy.MaxDelay
+	}
+
+	d := r.policy.BaseDelay << uint(attempt-1)
+	if d > r.policy.MaxDelay || d <= 0 {
+		d = r.policy.MaxDelay
+	}
+	if r.policy.Jitter > 0 {
+		d -= time.Duration(r.rand() * r.policy.Jitter * float64(d))
+	}
+
+	return d, true
+}
+
+// transient reports whether err is a network failure that may not happen again, i.e. a connection refused, reset or
+// closed, or a timeout. TLS handshake failures are not, since they are caused by the configuration of either end.
+func transient(err error) bool {
+	var (
+		alert  tls.AlertError
+		verify *tls.CertificateVerificationError
+		opErr  *net.OpError
+		netErr net.Error
+	)
+
+	switch {
+	case errors.As(err, &alert), errors.As(err, &verify):
+		return false
+	case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF), errors.Is(err, syscall.ECONNRESET):
+		return true
+	case errors.As(err, &opErr):
+		return true
+	default:
+		return errors.As(err, &netErr) && netErr.Timeout()
+	}
+}
+
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	if resp == nil {
+		return 0, false
+	}
+	v := resp.Header.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(v); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(v); err == nil {
+		if d := time.Until(date); d > 0 {
+			return d, true
+		}
+		return 0, true
+	}
+
+	return 0, false
+}
+
+// rewind returns the request to be sent at attempt. Requests after the first one are clones with a fresh body.
+func rewind(req *http.Request, attempt int) (*http.Request, error) {
+	if attempt == 1 {
+		return req, nil
+	}
+
+	clone := req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		clone.Body = body
+	}
+
+	return clone, nil
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// withAttempts returns a context in which retrier records the number of attempts made to complete a request.
+func withAttempts(ctx context.Context) (context.Context, *int) {
+	attempts := new(int)
+	return context.WithValue(ctx, attemptsKey{}, attempts), attempts
+}
+
+func recordAttempt(ctx context.Context, attempt int) {
+	if attempts, ok := ctx.Value(attemptsKey{}).(*int); ok {
+		*attempts = attempt
+	}
+}
+
+// attempt returns the number of the attempt a request is, as set by retrier in its context. It is 1 for requests that
+// are not retried.
+func attempt(ctx context.Context) int {
+	if n, ok := ctx.Value(attemptKey{}).(int); ok {
+		return n
+	}
+
+	return 1
+}
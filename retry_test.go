@@ -0,0 +1,230 @@
+package account
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"reflect"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type (
+	mockResponse struct {
+		status int
+		body   string
+		header http.Header
+		err    error
+	}
+	// mockTimeoutErr is a net.Error timing out.
+	mockTimeoutErr struct{}
+	// mockScriptedRequester answers each request with the next scripted response and records the requests received.
+	mockScriptedRequester struct {
+		responses []mockResponse
+		methods   []string
+		bodies    []string
+	}
+)
+
+func TestRetrier(t *testing.T) {
+	connReset := &url.Error{Op: "Delete", URL: "http://localhost", Err: &net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET}}
+	cases := []struct {
+		name      string
+		method    string
+		policy    RetryPolicy
+		responses []mockResponse
+		want      int
+		attempts  int
+	}{
+		{"status", _get, RetryPolicy{}, []mockResponse{{status: 503}, {status: 200}}, 200, 2},
+		{"connection reset", _delete, RetryPolicy{}, []mockResponse{{err: connReset}, {status: 204}}, 204, 2},
+		{"eof", _get, RetryPolicy{}, []mockResponse{{err: &url.Error{Op: "Get", Err: io.EOF}}, {status: 200}}, 200, 2},
+		{"timeout", _get, RetryPolicy{}, []mockResponse{{err: &url.Error{Op: "Get", Err: mockTimeoutErr{}}}, {status: 200}}, 200, 2},
+		{"retry after", _get, RetryPolicy{}, []mockResponse{{status: 429, header: http.Header{"Retry-After": {"5"}}}, {status: 200}}, 200, 2},
+		{"retry after over max delay", _get, RetryPolicy{}, []mockResponse{{status: 429, header: http.Header{"Retry-After": {"3600"}}}}, 429, 1},
+		{"exhausted", _get, RetryPolicy{}, []mockResponse{{status: 500}, {status: 502}, {status: 429}}, 429, 3},
+		{"max attempts", _get, RetryPolicy{MaxAttempts: 2}, []mockResponse{{status: 500}, {status: 502}}, 502, 2},
+		{"not retryable status", _get, RetryPolicy{}, []mockResponse{{status: 400}}, 400, 1},
+		{"custom retryable status", _get, RetryPolicy{RetryableStatus: []int{404}}, []mockResponse{{status: 404}, {status: 200}}, 200, 2},
+		{"create", _post, RetryPolicy{}, []mockResponse{{status: 503}}, 503, 1},
+		{"create opt in", _post, RetryPolicy{RetryCreate: true}, []mockResponse{{status: 503}, {status: 201}}, 201, 2},
+		{"update", _patch, RetryPolicy{RetryCreate: true}, []mockResponse{{status: 503}}, 503, 1},
+	}
+
+	for _, tt := range cases {
+		next := &mockScriptedRequester{responses: tt.responses}
+		r := newRetrier(next, tt.policy)
+		r.sleep = func(context.Context, time.Duration) error { return nil }
+		ctx, attempts := withAttempts(context.Background())
+		req, _ := http.NewRequestWithContext(ctx, tt.method, "http://localhost", bytes.NewBufferString("body"))
+
+		resp, err := r.Do(req)
+		if err != nil || resp.StatusCode != tt.want || *attempts != tt.attempts || len(next.methods) != tt.attempts {
+			t.Errorf("Retrier(%v) got: %v %v after %d attempts, want: %d after %d attempts", tt.name, resp, err, *attempts, tt.want, tt.attempts)
+		}
+		for _, body := range next.bodies {
+			if body != "body" {
+				t.Errorf("Retrier(%v) body got: %q, want: %q", tt.name, body, "body")
+			}
+		}
+	}
+}
+
+func TestRetrier_Error(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+	}{
+		{"configuration", errors.Wrap(errors.New("no PEM block found"), "signature")},
+		{"oauth2", errors.New("oauth2: token response: status_code: 401")},
+		{"tls alert", &url.Error{Op: "Get", Err: &net.OpError{Op: "remote error", Err: tls.AlertError(42)}}},
+		{"tls verification", &url.Error{Op: "Get", Err: &tls.CertificateVerificationError{Err: x509.UnknownAuthorityError{}}}},
+		{"circuit open", &CircuitOpenError{RetryAt: time.Now()}},
+	}
+
+	for _, tt := range cases {
+		next := &mockScriptedRequester{responses: []mockResponse{{err: tt.err}, {status: 200}}}
+		r := newRetrier(next, RetryPolicy{})
+		r.sleep = func(context.Context, time.Duration) error { return nil }
+		req, _ := http.NewRequest(_get, "http://localhost", nil)
+
+		if _, err := r.Do(req); !errors.Is(err, tt.err) || len(next.methods) != 1 {
+			t.Errorf("Retrier_Error(%v) got: %v after %d attempts, want: %v after 1 attempt", tt.name, err, len(next.methods), tt.err)
+		}
+	}
+}
+
+func TestRetrier_Delay(t *testing.T) {
+	date := time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)
+	cases := []struct {
+		name    string
+		policy  RetryPolicy
+		attempt int
+		header  http.Header
+		want    time.Duration
+		wantOK  bool
+	}{
+		{"first", RetryPolicy{}, 1, nil, 100 * time.Millisecond, true},
+		{"exponential", RetryPolicy{}, 3, nil, 400 * time.Millisecond, true},
+		{"max delay", RetryPolicy{MaxDelay: time.Second}, 10, nil, time.Second, true},
+		{"overflow", RetryPolicy{}, 80, nil, 5 * time.Second, true},
+		{"jitter", RetryPolicy{BaseDelay: time.Second, Jitter: 0.5}, 1, nil, 750 * time.Millisecond, true},
+		{"retry after seconds", RetryPolicy{MaxDelay: 10 * time.Second}, 1, http.Header{"Retry-After": []string{"7"}}, 7 * time.Second, true},
+		{"retry after over max delay", RetryPolicy{}, 1, http.Header{"Retry-After": []string{"3600"}}, time.Hour, false},
+		{"retry after past date", RetryPolicy{}, 1, http.Header{"Retry-After": []string{"Mon, 02 Jan 2006 15:04:05 GMT"}}, 0, true},
+		{"retry after invalid", RetryPolicy{}, 1, http.Header{"Retry-After": []string{"soon"}}, 100 * time.Millisecond, true},
+	}
+
+	for _, tt := range cases {
+		r := newRetrier(nil, tt.policy)
+		r.rand = func() float64 { return 0.5 }
+		if got, ok := r.delay(tt.attempt, &http.Response{Header: tt.header}); got != tt.want || ok != tt.wantOK {
+			t.Errorf("Retrier_Delay(%v) got: %v, %v, want: %v, %v", tt.name, got, ok, tt.want, tt.wantOK)
+		}
+	}
+
+	r := newRetrier(nil, RetryPolicy{MaxDelay: 2 * time.Hour})
+	if got, ok := r.delay(1, &http.Response{Header: http.Header{"Retry-After": []string{date}}}); got < 59*time.Minute || !ok {
+		t.Errorf("Retrier_Delay(retry after date) got: %v, %v, want: ~1h, true", got, ok)
+	}
+}
+
+func TestRetrier_Context(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	next := &mockScriptedRequester{responses: []mockResponse{{status: 503}, {status: 200}}}
+	r := newRetrier(next, RetryPolicy{BaseDelay: time.Hour})
+	req, _ := http.NewRequestWithContext(ctx, _get, "http://localhost", nil)
+
+	time.AfterFunc(10*time.Millisecond, cancel)
+	_, err := r.Do(req)
+	if !errors.Is(err, context.Canceled) || len(next.methods) != 1 {
+		t.Errorf("Retrier_Context got: %v after %d attempts, want: %v after 1 attempt", err, len(next.methods), context.Canceled)
+	}
+}
+
+func TestRepositoryCreate_RetriedConflict(t *testing.T) {
+	fetched, _ := json.Marshal(payload{Data: &_accountStub})
+	next := &mockScriptedRequester{responses: []mockResponse{
+		{status: 503},
+		{status: 409, body: `{"error_message":"Account cannot be created as it violates a duplicate constraint"}`},
+		{status: 200, body: string(fetched)},
+	}}
+	retry := newRetrier(next, RetryPolicy{RetryCreate: true})
+	retry.sleep = func(context.Context, time.Duration) error { return nil }
+	repo := NewHTTPRepository()
+	repo.client = httpclient{errCtx: "http_client", buildRequest: http.NewRequestWithContext, Requester: retry}
+
+	got, err := repo.Create(context.Background(), _accountStub)
+	if err != nil || !reflect.DeepEqual(*got, _accountStub) {
+		t.Errorf("RepositoryCreate_RetriedConflict got: %v, %v, want: %v", got, err, _accountStub)
+	}
+	if want := []string{_post, _post, _get}; !reflect.DeepEqual(next.methods, want) {
+		t.Errorf("RepositoryCreate_RetriedConflict methods got: %v, want: %v", next.methods, want)
+	}
+}
+
+func TestRepositoryCreate_RetriedConflictMaxInFlight(t *testing.T) {
+	fetched, _ := json.Marshal(payload{Data: &_accountStub})
+	next := &mockScriptedRequester{responses: []mockResponse{
+		{status: 503},
+		{status: 409, body: `{"error_message":"Account cannot be created as it violates a duplicate constraint"}`},
+		{status: 200, body: string(fetched)},
+	}}
+	retry := newRetrier(newConcurrencyLimiter(next, 1), RetryPolicy{RetryCreate: true})
+	retry.sleep = func(context.Context, time.Duration) error { return nil }
+	repo := NewHTTPRepository()
+	repo.client = httpclient{errCtx: "http_client", buildRequest: http.NewRequestWithContext, Requester: retry}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	got, err := repo.Create(ctx, _accountStub)
+	if err != nil || !reflect.DeepEqual(*got, _accountStub) {
+		t.Errorf("RepositoryCreate_RetriedConflictMaxInFlight got: %v, %v, want: %v", got, err, _accountStub)
+	}
+}
+
+func TestRepositoryCreate_Conflict(t *testing.T) {
+	next := &mockScriptedRequester{responses: []mockResponse{{status: 409}}}
+	repo := NewHTTPRepository()
+	repo.client = httpclient{errCtx: "http_client", buildRequest: http.NewRequestWithContext, Requester: newRetrier(next, RetryPolicy{RetryCreate: true})}
+
+	if _, err := repo.Create(context.Background(), _accountStub); !errors.Is(err, ErrConflict) || len(next.methods) != 1 {
+		t.Errorf("RepositoryCreate_Conflict got: %v, want: %v", err, ErrConflict)
+	}
+}
+
+func (mockTimeoutErr) Error() string   { return "i/o timeout" }
+func (mockTimeoutErr) Timeout() bool   { return true }
+func (mockTimeoutErr) Temporary() bool { return true }
+
+func (m *mockScriptedRequester) Do(req *http.Request) (*http.Response, error) {
+	m.methods = append(m.methods, req.Method)
+	if req.Body != nil {
+		b, _ := io.ReadAll(req.Body)
+		m.bodies = append(m.bodies, string(b))
+	}
+
+	r := m.responses[0]
+	if len(m.responses) > 1 {
+		m.responses = m.responses[1:]
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	return &http.Response{
+		StatusCode: r.status,
+		Header:     r.header,
+		Body:       mockCloser{bytes.NewBufferString(r.body)},
+		Request:    req,
+	}, nil
+}
@@ -0,0 +1,217 @@
+package account
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type (
+	// HTTPSignature configures the signing of account-api requests following HTTP Signatures
+	// (draft-cavage-http-signatures).
+	//
+	// See: https://datatracker.ietf.org/doc/html/draft-cavage-http-signatures-12
+	HTTPSignature struct {
+		// KeyID identifies the key to account-api. (REQUIRED)
+		KeyID string
+		// PrivateKey is the PEM encoded RSA or ECDSA private key, either in PKCS #1, SEC 1 or PKCS #8 form. (REQUIRED)
+		PrivateKey []byte
+		// Headers are the names of the headers to be signed, in order. (request-target) and host are supported as
+		// pseudo headers, and content-length falls back to the length of the body when the header is not set.
+		// Default is (request-target), date and digest. (OPTIONAL)
+		Headers []string
+		// SignatureHeader sends the signature in the Signature header instead of the Authorization one. It is forced
+		// along with WithOAuth2ClientCredentials, since the Authorization header holds the bearer token. (OPTIONAL)
+		SignatureHeader bool
+	}
+	signer struct {
+		Requester
+		keyID     string
+		key       crypto.Signer
+		algorithm string
+		headers   []string
+		header    string
+		now       func() time.Time
+	}
+)
+
+const (
+	_requestTarget   = "(request-target)"
+	_host            = "host"
+	_contentLength   = "content-length"
+	_dateHeader      = "Date"
+	_digestHeader    = "Digest"
+	_authHeader      = "Authorization"
+	_signatureHeader = "Signature"
+)
+
+var _defaultSignedHeaders = []string{_requestTarget, "date", "digest"}
+
+func newSigner(next Requester, hs HTTPSignature) (signer, error) {
+	key, algorithm, err := parsePrivateKey(hs.PrivateKey)
+	if err != nil {
+		return signer{}, err
+	}
+
+	headers := hs.Headers
+	if len(headers) == 0 {
+		headers = _defaultSignedHeaders
+	}
+	header := _authHeader
+	if hs.SignatureHeader {
+		header = _signatureHeader
+	}
+
+	return signer{
+		Requester: next,
+		keyID:     hs.KeyID,
+		key:       key,
+		algorithm: algorithm,
+		headers:   headers,
+		header:    header,
+		now:       time.Now,
+	}, nil
+}
+
+// HTTPSignatureMiddleware signs requests according to HTTPSignature, as WithHTTPSignature does. If the private key
+// cannot be parsed, every request fails with the parse error.
+func HTTPSignatureMiddleware(hs HTTPSignature) Middleware {
+	return func(next Requester) Requester {
+		s, err := newSigner(next, hs)
+		if err != nil {
+			return errRequester{errors.Wrap(err, "signature")}
+		}
+		return s
+	}
+}
+
+// Do signs a copy of req, adding Date and Digest headers when they are missing, and sends it.
+func (s signer) Do(req *http.Request) (*http.Response, error) {
+	signed, err := s.sign(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "signature")
+	}
+
+	return s.Requester.Do(signed)
+}
+
+func (s signer) sign(req *http.Request) (*http.Request, error) {
+	signed := req.Clone(req.Context())
+
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return nil, errors.Wrap(err, "read body")
+		}
+		signed.Body = io.NopCloser(bytes.NewReader(body))
+		signed.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(body)), nil }
+	}
+
+	if signed.Header.Get(_dateHeader) == "" {
+		signed.Header.Set(_dateHeader, s.now().UTC().Format(http.TimeFormat))
+	}
+	if signed.Header.Get(_digestHeader) == "" {
+		sum := sha256.Sum256(body)
+		signed.Header.Set(_digestHeader, "SHA-256="+base64.StdEncoding.EncodeToString(sum[:]))
+	}
+
+	toSign, err := signingString(signed, s.headers)
+	if err != nil {
+		return nil, err
+	}
+	hashed := sha256.Sum256([]byte(toSign))
+	sig, err := s.key.Sign(rand.Reader, hashed[:], crypto.SHA256)
+	if err != nil {
+		return nil, errors.Wrap(err, "sign")
+	}
+
+	params := fmt.Sprintf(`keyId="%s",algorithm="%s",headers="%s",signature="%s"`,
+		s.keyID, s.algorithm, strings.Join(s.headers, " "), base64.StdEncoding.EncodeToString(sig))
+	if s.header == _authHeader {
+		params = "Signature " + params
+	}
+	signed.Header.Set(s.header, params)
+
+	return signed, nil
+}
+
+// signingString builds the string to be signed out of the given headers of req, one "name: value" per line.
+func signingString(req *http.Request, headers []string) (string, error) {
+	lines := make([]string, len(headers))
+	for i, h := range headers {
+		name := strings.ToLower(h)
+		var value string
+		switch name {
+		case _requestTarget:
+			value = fmt.Sprintf("%s %s", strings.ToLower(req.Method), req.URL.RequestURI())
+		case _host:
+			value = req.Host
+			if value == "" {
+				value = req.URL.Host
+			}
+		case _contentLength:
+			value = req.Header.Get(name)
+			if value == "" {
+				value = strconv.FormatInt(req.ContentLength, 10)
+			}
+		default:
+			values, ok := req.Header[http.CanonicalHeaderKey(name)]
+			if !ok {
+				return "", errors.Errorf("header %s not found", name)
+			}
+			value = strings.Join(values, ", ")
+		}
+		lines[i] = fmt.Sprintf("%s: %s", name, value)
+	}
+
+	return strings.Join(lines, "\n"), nil
+}
+
+func parsePrivateKey(keyPEM []byte) (crypto.Signer, string, error) {
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return nil, "", errors.New("private key: no PEM block found")
+	}
+
+	var (
+		key interface{}
+		err error
+	)
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		return nil, "", errors.Errorf("private key: unsupported PEM block %s", block.Type)
+	}
+	if err != nil {
+		return nil, "", errors.Wrap(err, "private key")
+	}
+
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return k, "rsa-sha256", nil
+	case *ecdsa.PrivateKey:
+		return k, "ecdsa-sha256", nil
+	default:
+		return nil, "", errors.Errorf("private key: unsupported type %T", k)
+	}
+}
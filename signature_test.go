@@ -0,0 +1,210 @@
+package account
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var _signatureParamsRegexp = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+func TestHTTPSignature(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecDER, _ := x509.MarshalECPrivateKey(ecKey)
+	pkcs8DER, _ := x509.MarshalPKCS8PrivateKey(rsaKey)
+	cases := []struct {
+		name string
+		in   HTTPSignature
+		pub  crypto.PublicKey
+	}{
+		{"rsa pkcs1", HTTPSignature{KeyID: "rsa", PrivateKey: encodePEM("RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey))}, &rsaKey.PublicKey},
+		{"rsa pkcs8", HTTPSignature{KeyID: "rsa", PrivateKey: encodePEM("PRIVATE KEY", pkcs8DER)}, &rsaKey.PublicKey},
+		{"ecdsa", HTTPSignature{KeyID: "ec", PrivateKey: encodePEM("EC PRIVATE KEY", ecDER)}, &ecKey.PublicKey},
+		{"signature header", HTTPSignature{KeyID: "ec", PrivateKey: encodePEM("EC PRIVATE KEY", ecDER), SignatureHeader: true}, &ecKey.PublicKey},
+		{"custom headers", HTTPSignature{KeyID: "ec", PrivateKey: encodePEM("EC PRIVATE KEY", ecDER), Headers: []string{"(request-target)", "host", "date", "digest", "content-length"}}, &ecKey.PublicKey},
+	}
+
+	for _, tt := range cases {
+		srv := httptest.NewServer(verifySignature(t, tt.in, tt.pub))
+		baseURL, _ := url.Parse(srv.URL)
+		repo := NewHTTPRepository(WithBaseURL(baseURL), WithHTTPSignature(tt.in))
+
+		if _, err := repo.Create(context.Background(), _accountStub); err != nil {
+			t.Errorf("HTTPSignature(%v) create got: %v, want: nil", tt.name, err)
+		}
+		if _, err := repo.Fetch(context.Background(), _fakeStubID); err != nil {
+			t.Errorf("HTTPSignature(%v) fetch got: %v, want: nil", tt.name, err)
+		}
+		srv.Close()
+	}
+}
+
+func TestHTTPSignature_OAuth2(t *testing.T) {
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	ecDER, _ := x509.MarshalECPrivateKey(ecKey)
+	hs := HTTPSignature{KeyID: "ec", PrivateKey: encodePEM("EC PRIVATE KEY", ecDER)}
+	tokenSrv := httptest.NewServer(&mockTokenEndpoint{expiresIn: 3600})
+	defer tokenSrv.Close()
+	signed := verifySignature(t, HTTPSignature{KeyID: hs.KeyID, SignatureHeader: true}, &ecKey.PublicKey)
+	apiSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token := strings.TrimPrefix(r.Header.Get(_authHeader), "Bearer "); token != "token-1" {
+			t.Logf("HTTPSignature_OAuth2 authorization: %s", r.Header.Get(_authHeader))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		signed.ServeHTTP(w, r)
+	}))
+	defer apiSrv.Close()
+	baseURL, _ := url.Parse(apiSrv.URL)
+	repo := NewHTTPRepository(WithBaseURL(baseURL), WithHTTPSignature(hs), WithOAuth2ClientCredentials(tokenSrv.URL, "client", "s3cr3t"))
+
+	if _, err := repo.Create(context.Background(), _accountStub); err != nil {
+		t.Errorf("HTTPSignature_OAuth2 create got: %v, want: nil", err)
+	}
+	if _, err := repo.Fetch(context.Background(), _fakeStubID); err != nil {
+		t.Errorf("HTTPSignature_OAuth2 fetch got: %v, want: nil", err)
+	}
+}
+
+func TestHTTPSignature_Retry(t *testing.T) {
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	ecDER, _ := x509.MarshalECPrivateKey(ecKey)
+	next := &mockScriptedRequester{responses: []mockResponse{{status: 503}, {status: 200}}}
+	s, err := newSigner(next, HTTPSignature{KeyID: "ec", PrivateKey: encodePEM("EC PRIVATE KEY", ecDER)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2021, 7, 1, 12, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { now = now.Add(time.Second); return now }
+	r := newRetrier(s, RetryPolicy{})
+	r.sleep = func(context.Context, time.Duration) error { return nil }
+	req, _ := http.NewRequest(_get, "http://localhost/v1/health", nil)
+
+	if _, err := r.Do(req); err != nil {
+		t.Fatal(err)
+	}
+	if req.Header.Get(_authHeader) != "" || req.Header.Get(_dateHeader) != "" {
+		t.Errorf("HTTPSignature_Retry changed the original request: %v", req.Header)
+	}
+	if len(next.methods) != 2 {
+		t.Errorf("HTTPSignature_Retry attempts got: %d, want: 2", len(next.methods))
+	}
+}
+
+func TestHTTPSignature_Error(t *testing.T) {
+	_, ed25519Key, _ := ed25519.GenerateKey(rand.Reader)
+	ed25519DER, _ := x509.MarshalPKCS8PrivateKey(ed25519Key)
+	cases := []struct {
+		name string
+		in   []byte
+		want string
+	}{
+		{"no pem", []byte("key"), "private key: no PEM block found"},
+		{"unsupported block", encodePEM("PUBLIC KEY", []byte("key")), "private key: unsupported PEM block PUBLIC KEY"},
+		{"invalid key", encodePEM("RSA PRIVATE KEY", []byte("key")), "private key: asn1: structure error"},
+		{"unsupported type", encodePEM("PRIVATE KEY", ed25519DER), "private key: unsupported type ed25519.PrivateKey"},
+	}
+
+	for _, tt := range cases {
+		_, got := newHTTPClient(httpOptions{signature: &HTTPSignature{PrivateKey: tt.in}}).request(context.Background(), _get, "http://localhost", nil)
+		want := "http_client#request() do: signature: " + tt.want
+		if got == nil || !strings.HasPrefix(got.Error(), want) {
+			t.Errorf("HTTPSignature_Error(%v) got: %v, want: %v", tt.name, got, want)
+		}
+	}
+}
+
+func TestSigningString_Error(t *testing.T) {
+	req, _ := http.NewRequest(_get, "http://localhost", nil)
+	want := "header content-type not found"
+
+	if _, got := signingString(req, []string{"content-type"}); got == nil || got.Error() != want {
+		t.Errorf("SigningString_Error got: %v, want: %v", got, want)
+	}
+}
+
+// verifySignature returns a handler acting as account-api that answers 401 unless the request is signed by the key
+// matching pub, with the headers configured in hs.
+func verifySignature(t *testing.T, hs HTTPSignature, pub crypto.PublicKey) http.Handler {
+	verify := func(r *http.Request) error {
+		header := r.Header.Get(_authHeader)
+		if hs.SignatureHeader {
+			header = r.Header.Get(_signatureHeader)
+		} else if !strings.HasPrefix(header, "Signature ") {
+			return errors.Errorf("authorization: %s", header)
+		}
+		params := map[string]string{}
+		for _, m := range _signatureParamsRegexp.FindAllStringSubmatch(header, -1) {
+			params[m[1]] = m[2]
+		}
+		if params["keyId"] != hs.KeyID {
+			return errors.Errorf("keyId: %s", params["keyId"])
+		}
+
+		body, _ := io.ReadAll(r.Body)
+		sum := sha256.Sum256(body)
+		if digest := "SHA-256=" + base64.StdEncoding.EncodeToString(sum[:]); r.Header.Get(_digestHeader) != digest {
+			return errors.Errorf("digest: %s, want: %s", r.Header.Get(_digestHeader), digest)
+		}
+
+		ss, err := signingString(r, strings.Split(params["headers"], " "))
+		if err != nil {
+			return err
+		}
+		hashed := sha256.Sum256([]byte(ss))
+		sig, _ := base64.StdEncoding.DecodeString(params["signature"])
+
+		switch k := pub.(type) {
+		case *rsa.PublicKey:
+			return rsa.VerifyPKCS1v15(k, crypto.SHA256, hashed[:], sig)
+		case *ecdsa.PublicKey:
+			if !ecdsa.VerifyASN1(k, hashed[:], sig) {
+				return errors.New("ecdsa: invalid signature")
+			}
+		}
+		return nil
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := verify(r); err != nil {
+			t.Logf("verifySignature: %v", err)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		status := http.StatusOK
+		if r.Method == _post {
+			status = http.StatusCreated
+		}
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(`{"data":{"id":"ad27e265-9605-4b4b-a0e5-3003ea9cc4d2"}}`))
+	})
+}
+
+func encodePEM(typ string, der []byte) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der})
+}
@@ -0,0 +1,156 @@
+package account
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+type (
+	// tracer opens a client span per attempt of a request sent to account-api and propagates it to account-api
+	// through the W3C traceparent header.
+	tracer struct {
+		Requester
+		tracer     trace.Tracer
+		propagator propagation.TextMapPropagator
+		redact     map[string]bool
+	}
+	// serviceSpan is the span of a Service call.
+	serviceSpan struct {
+		trace.Span
+		retries *int32
+	}
+	retriesKey           struct{}
+	tracerProviderOption struct{ trace.TracerProvider }
+)
+
+const (
+	_instrumentationName = "github.com/r1cm3d/account-lib"
+
+	_attrAccountID      = attribute.Key("account.id")
+	_attrOrganisationID = attribute.Key("account.organisation_id")
+	_attrCountry        = attribute.Key("account.country")
+	_attrRetryCount     = attribute.Key("account.retry_count")
+	_attrMethod         = attribute.Key("http.request.method")
+	_attrURL            = attribute.Key("url.full")
+	_attrStatusCode     = attribute.Key("http.response.status_code")
+	_attrResendCount    = attribute.Key("http.request.resend_count")
+)
+
+// WithTracerProvider enables OpenTelemetry tracing with tracers of tp. Given to NewService, it opens a span per
+// Service call recording the account ID, organisation ID and country involved, and the number of retries made. Given
+// to NewHTTPRepository, it opens a client span per attempt of every request sent to account-api, as
+// TracingMiddleware does. Tracing is disabled by default.
+//
+// Example:
+//
+//	tracing := acc.WithTracerProvider(otel.GetTracerProvider())
+//	svc := acc.NewService(acc.NewHTTPRepository(tracing), tracing)
+func WithTracerProvider(tp trace.TracerProvider) tracerProviderOption {
+	return tracerProviderOption{tp}
+}
+
+// TracingMiddleware opens a client span with tracers of tp for every attempt of a request sent to account-api, as
+// WithTracerProvider does. The span records the method, URL, status code and resend count of the attempt, and is
+// propagated to account-api through the W3C traceparent header.
+func TracingMiddleware(tp trace.TracerProvider) Middleware {
+	redact := make(map[string]bool, len(_defaultRedact))
+	for _, f := range _defaultRedact {
+		redact[f] = true
+	}
+
+	return func(next Requester) Requester {
+		return tracer{
+			Requester:  next,
+			tracer:     tp.Tracer(_instrumentationName),
+			propagator: propagation.TraceContext{},
+			redact:     redact,
+		}
+	}
+}
+
+func (t tracer) Do(req *http.Request) (*http.Response, error) {
+	n := attempt(req.Context())
+	ctx, span := t.tracer.Start(req.Context(), req.Method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(_attrMethod.String(req.Method), _attrURL.String(redactURL(req.URL, t.redact))),
+	)
+	defer span.End()
+	if n > 1 {
+		span.SetAttributes(_attrResendCount.Int(n - 1))
+	}
+
+	traced := req.Clone(ctx)
+	t.propagator.Inject(ctx, propagation.HeaderCarrier(traced.Header))
+
+	resp, err := t.Requester.Do(traced)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	span.SetAttributes(_attrStatusCode.Int(resp.StatusCode))
+	if resp.StatusCode >= http.StatusBadRequest {
+		span.SetStatus(codes.Error, http.StatusText(resp.StatusCode))
+	}
+
+	return resp, nil
+}
+
+// startSpan opens the span of a Service call of op. The number of retries made to complete the call is recorded
+// when it ends.
+func (s Service) startSpan(ctx context.Context, op Operation, attrs ...attribute.KeyValue) (context.Context, serviceSpan) {
+	t := s.tracer
+	if t == nil {
+		t = noop.NewTracerProvider().Tracer(_instrumentationName)
+	}
+
+	ctx, retries := withRetries(ctx)
+	ctx, span := t.Start(ctx, "account."+string(op), trace.WithAttributes(attrs...))
+
+	return ctx, serviceSpan{Span: span, retries: retries}
+}
+
+// end records acc, if any, and err, if any, and ends the span.
+func (s serviceSpan) end(acc *Entity, err error) {
+	if acc != nil {
+		s.SetAttributes(
+			_attrAccountID.String(acc.ID()),
+			_attrOrganisationID.String(acc.OrganisationID().String()),
+			_attrCountry.String(string(acc.Country())),
+		)
+	}
+	s.SetAttributes(_attrRetryCount.Int(int(atomic.LoadInt32(s.retries))))
+	if err != nil {
+		s.RecordError(err)
+		s.SetStatus(codes.Error, err.Error())
+	}
+	s.End()
+}
+
+// withRetries returns a context in which retrier counts the retries made to complete a call, across all the requests
+// it involves.
+func withRetries(ctx context.Context) (context.Context, *int32) {
+	retries := new(int32)
+	return context.WithValue(ctx, retriesKey{}, retries), retries
+}
+
+func recordRetry(ctx context.Context) {
+	if retries, ok := ctx.Value(retriesKey{}).(*int32); ok {
+		atomic.AddInt32(retries, 1)
+	}
+}
+
+func (t tracerProviderOption) apply(opts *httpOptions) {
+	opts.tracerProvider = t.TracerProvider
+}
+
+func (t tracerProviderOption) applyService(opts *serviceOptions) {
+	opts.tracerProvider = t.TracerProvider
+}
@@ -0,0 +1,109 @@
+package account
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestWithTracerProvider(t *testing.T) {
+	var calls int32
+	var parents []trace.SpanContext
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := propagation.TraceContext{}.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		parents = append(parents, trace.SpanContextFromContext(ctx))
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write(body)
+	}))
+	defer srv.Close()
+	baseURL, _ := url.Parse(srv.URL)
+	recorder := tracetest.NewSpanRecorder()
+	tracing := WithTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	repo := NewHTTPRepository(WithBaseURL(baseURL), WithRetryPolicy(RetryPolicy{BaseDelay: time.Millisecond, RetryCreate: true}), tracing)
+	svc := NewService(repo, tracing)
+
+	if _, err := svc.Create(_basicFilledCreateRequest); err != nil {
+		t.Fatal(err)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 3 {
+		t.Fatalf("WithTracerProvider spans got: %d, want: 3", len(spans))
+	}
+	first, second, service := spans[0], spans[1], spans[2]
+	if service.Name() != "account.create" || first.Name() != _post || second.Name() != _post {
+		t.Errorf("WithTracerProvider span names got: %s, %s, %s, want: POST, POST, account.create", first.Name(), second.Name(), service.Name())
+	}
+	for i, s := range []sdktrace.ReadOnlySpan{first, second} {
+		if s.Parent().SpanID() != service.SpanContext().SpanID() || s.SpanKind() != trace.SpanKindClient {
+			t.Errorf("WithTracerProvider attempt %d got: parent %v, kind %v, want: child of the service span", i+1, s.Parent().SpanID(), s.SpanKind())
+		}
+		if len(parents) <= i || parents[i].SpanID() != s.SpanContext().SpanID() {
+			t.Errorf("WithTracerProvider attempt %d traceparent got: %v, want: %v", i+1, parents, s.SpanContext().SpanID())
+		}
+	}
+
+	cases := []struct {
+		name string
+		span sdktrace.ReadOnlySpan
+		key  attribute.Key
+		want attribute.Value
+	}{
+		{"first status", first, _attrStatusCode, attribute.IntValue(503)},
+		{"second status", second, _attrStatusCode, attribute.IntValue(201)},
+		{"second resend count", second, _attrResendCount, attribute.IntValue(1)},
+		{"url", second, _attrURL, attribute.StringValue(srv.URL + "/v1/organisation/accounts")},
+		{"account id", service, _attrAccountID, attribute.StringValue(_fakeStubID)},
+		{"organisation id", service, _attrOrganisationID, attribute.StringValue(_organisationIDStub)},
+		{"country", service, _attrCountry, attribute.StringValue(_countryStub)},
+		{"retry count", service, _attrRetryCount, attribute.IntValue(1)},
+	}
+	for _, tt := range cases {
+		if got, ok := spanAttribute(tt.span, tt.key); !ok || got != tt.want {
+			t.Errorf("WithTracerProvider(%v) got: %v, want: %v", tt.name, got.Emit(), tt.want.Emit())
+		}
+	}
+	if _, ok := spanAttribute(first, _attrResendCount); ok {
+		t.Errorf("WithTracerProvider(first resend count) got: set, want: unset")
+	}
+}
+
+func TestWithTracerProvider_Error(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	svc := Service{errCtx: "service", retriever: mockNotFound{}, tracer: tp.Tracer(_instrumentationName)}
+
+	_, err := svc.FetchContext(context.Background(), _fakeStubID)
+
+	spans := recorder.Ended()
+	if len(spans) != 1 || spans[0].Name() != "account.fetch" || spans[0].Status().Code != codes.Error || spans[0].Status().Description != err.Error() {
+		t.Errorf("WithTracerProvider_Error got: %v, want: an account.fetch span with error %v", spans, err)
+	}
+}
+
+func spanAttribute(s sdktrace.ReadOnlySpan, key attribute.Key) (attribute.Value, bool) {
+	for _, a := range s.Attributes() {
+		if a.Key == key {
+			return a.Value, true
+		}
+	}
+
+	return attribute.Value{}, false
+}
@@ -0,0 +1,157 @@
+package account
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+type (
+	// FieldError describes why a field of a request is invalid.
+	FieldError struct {
+		// Field is the path of the offending field, e.g. ID or Name[1].
+		Field string
+		// Message tells what is wrong with the field.
+		Message string
+	}
+	// ValidationError is returned when a request is refused before being sent to account-api. It holds one
+	// FieldError per offending field, in the order of the fields of the request, and matches ErrInvalidRequest with
+	// errors.Is:
+	//
+	//	var vErr *account.ValidationError
+	//	if errors.As(err, &vErr) {
+	//		for _, f := range vErr.Fields {
+	//			log.Println(f.Field, f.Message)
+	//		}
+	//	}
+	ValidationError struct {
+		Fields []FieldError
+	}
+	validationOption struct {
+		enabled bool
+	}
+)
+
+const (
+	_maxNameLines        = 4
+	_maxAlternativeNames = 3
+)
+
+var (
+	// _countries holds the ISO 3166-1 alpha-2 codes.
+	_countries = codeSet(`
+		AD AE AF AG AI AL AM AO AQ AR AS AT AU AW AX AZ BA BB BD BE BF BG BH BI BJ BL BM BN BO BQ BR BS BT BV BW BY BZ
+		CA CC CD CF CG CH CI CK CL CM CN CO CR CU CV CW CX CY CZ DE DJ DK DM DO DZ EC EE EG EH ER ES ET FI FJ FK FM FO
+		FR GA GB GD GE GF GG GH GI GL GM GN GP GQ GR GS GT GU GW GY HK HM HN HR HT HU ID IE IL IM IN IO IQ IR IS IT JE
+		JM JO JP KE KG KH KI KM KN KP KR KW KY KZ LA LB LC LI LK LR LS LT LU LV LY MA MC MD ME MF MG MH MK ML MM MN MO
+		MP MQ MR MS MT MU MV MW MX MY MZ NA NC NE NF NG NI NL NO NP NR NU NZ OM PA PE PF PG PH PK PL PM PN PR PS PT PW
+		PY QA RE RO RS RU RW SA SB SC SD SE SG SH SI SJ SK SL SM SN SO SR SS ST SV SX SY SZ TC TD TF TG TH TJ TK TL TM
+		TN TO TR TT TV TW TZ UA UG UM US UY UZ VA VC VE VG VI VN VU WF WS YE YT ZA ZM ZW`)
+	// _currencies holds the active ISO 4217 codes.
+	_currencies = codeSet(`
+		AED AFN ALL AMD ANG AOA ARS AUD AWG AZN BAM BBD BDT BGN BHD BIF BMD BND BOB BOV BRL BSD BTN BWP BYN BZD CAD CDF
+		CHE CHF CHW CLF CLP CNY COP COU CRC CUP CVE CZK DJF DKK DOP DZD EGP ERN ETB EUR FJD FKP GBP GEL GHS GIP GMD GNF
+		GTQ GYD HKD HNL HTG HUF IDR ILS INR IQD IRR ISK JMD JOD JPY KES KGS KHR KMF KPW KRW KWD KYD KZT LAK LBP LKR LRD
+		LSL LYD MAD MDL MGA MKD MMK MNT MOP MRU MUR MVR MWK MXN MXV MYR MZN NAD NGN NIO NOK NPR NZD OMR PAB PEN PGK PHP
+		PKR PLN PYG QAR RON RSD RUB RWF SAR SBD SCR SDG SEK SGD SHP SLE SLL SOS SRD SSP STN SVC SYP SZL THB TJS TMT TND
+		TOP TRY TTD TWD TZS UAH UGX USD USN UYI UYU UYW UZS VED VES VND VUV WST XAF XAG XAU XBA XBB XBC XBD XCD XDR XOF
+		XPD XPF XPT XSU XTS XUA XXX YER ZAR ZMW ZWL`)
+)
+
+// WithValidation enables or disables the validation of CreateRequest by Service.Create. Validation is enabled by
+// default; disable it to let account-api be the only judge, e.g. for attributes it accepts that Validate does not.
+func WithValidation(enabled bool) validationOption {
+	return validationOption{enabled}
+}
+
+// Validate checks CreateRequest before it is sent to account-api:
+//
+// ID must be a UUID 4 and OrganisationID a UUID, both in the canonical xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx form.
+//
+// Country must be an ISO 3166-1 alpha-2 code and BaseCurrency, when given, an ISO 4217 code.
+//
+// Bic, when given, must have 8 or 11 characters.
+//
+// Name must have 1 to 4 non-blank lines and AlternativeNames at most 3.
+//
+// Returns nil when CreateRequest is valid, a *ValidationError with an entry per offending field otherwise.
+func (cr CreateRequest) Validate() error {
+	var fields []FieldError
+	invalid := func(field, format string, args ...interface{}) {
+		fields = append(fields, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if id, ok := parseUUID(cr.ID); !ok || id.Version() != 4 {
+		invalid("ID", "must be a UUID 4, got: %q", cr.ID)
+	}
+	if _, ok := parseUUID(cr.OrganisationID); !ok {
+		invalid("OrganisationID", "must be a UUID, got: %q", cr.OrganisationID)
+	}
+	if !_countries[cr.Country] {
+		invalid("Country", "must be an ISO 3166-1 alpha-2 code, got: %q", cr.Country)
+	}
+	if cr.BaseCurrency != "" && !_currencies[cr.BaseCurrency] {
+		invalid("BaseCurrency", "must be an ISO 4217 code, got: %q", cr.BaseCurrency)
+	}
+	if n := len(cr.Bic); n != 0 && n != 8 && n != 11 {
+		invalid("Bic", "must have 8 or 11 characters, got: %d", n)
+	}
+	if n := len(cr.Name); n == 0 || n > _maxNameLines {
+		invalid("Name", "must have 1 to %d lines, got: %d", _maxNameLines, n)
+	}
+	for i, line := range cr.Name {
+		if strings.TrimSpace(line) == "" {
+			invalid(fmt.Sprintf("Name[%d]", i), "must not be blank")
+		}
+	}
+	if n := len(cr.AlternativeNames); n > _maxAlternativeNames {
+		invalid("AlternativeNames", "must have at most %d names, got: %d", _maxAlternativeNames, n)
+	}
+
+	if fields == nil {
+		return nil
+	}
+
+	return &ValidationError{Fields: fields}
+}
+
+// Error formats ValidationError as: invalid request: <field>: <message>; <field>: <message>...
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		msgs[i] = fmt.Sprintf("%s: %s", f.Field, f.Message)
+	}
+
+	return fmt.Sprintf("%v: %s", ErrInvalidRequest, strings.Join(msgs, "; "))
+}
+
+// Is reports whether target is ErrInvalidRequest.
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrInvalidRequest
+}
+
+func (v validationOption) applyService(opts *serviceOptions) {
+	opts.skipValidation = !v.enabled
+}
+
+// parseUUID parses s as a UUID in the canonical form only, unlike uuid.Parse which accepts the urn:uuid: prefix,
+// braces and no hyphens too.
+func parseUUID(s string) (uuid.UUID, bool) {
+	const canonicalLen = 36
+	if len(s) != canonicalLen {
+		return uuid.UUID{}, false
+	}
+	id, err := uuid.Parse(s)
+
+	return id, err == nil
+}
+
+func codeSet(codes string) map[string]bool {
+	set := map[string]bool{}
+	for _, c := range strings.Fields(codes) {
+		set[c] = true
+	}
+
+	return set
+}
@@ -0,0 +1,99 @@
+package account
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestCreateRequest_Validate(t *testing.T) {
+	invalid := func(modify func(cr *CreateRequest)) CreateRequest {
+		cr := _fullyFilledCreateRequest
+		modify(&cr)
+		return cr
+	}
+
+	cases := []struct {
+		name string
+		in   CreateRequest
+		want []string
+	}{
+		{"fully filled", _fullyFilledCreateRequest, nil},
+		{"basic filled", _basicFilledCreateRequest, nil},
+		{"bic 11 chars", invalid(func(cr *CreateRequest) { cr.Bic = "NWBKGB22XXX" }), nil},
+		{"no base currency", invalid(func(cr *CreateRequest) { cr.BaseCurrency = "" }), nil},
+		{"four name lines", invalid(func(cr *CreateRequest) { cr.Name = []string{"A", "B", "C", "D"} }), nil},
+		{"empty", CreateRequest{}, []string{"ID", "OrganisationID", "Country", "Name"}},
+		{"UUID 1 ID", invalid(func(cr *CreateRequest) { cr.ID = "6ba7b810-9dad-11d1-80b4-00c04fd430c8" }), []string{"ID"}},
+		{"URN ID", invalid(func(cr *CreateRequest) { cr.ID = "urn:uuid:" + _idStub }), []string{"ID"}},
+		{"braced ID", invalid(func(cr *CreateRequest) { cr.ID = "{" + _idStub + "}" }), []string{"ID"}},
+		{"organisation ID", invalid(func(cr *CreateRequest) { cr.OrganisationID = "org" }), []string{"OrganisationID"}},
+		{"URN organisation ID", invalid(func(cr *CreateRequest) { cr.OrganisationID = "urn:uuid:" + _organisationIDStub }), []string{"OrganisationID"}},
+		{"braced organisation ID", invalid(func(cr *CreateRequest) { cr.OrganisationID = "{" + _organisationIDStub + "}" }), []string{"OrganisationID"}},
+		{"alpha-3 country", invalid(func(cr *CreateRequest) { cr.Country = "GBR" }), []string{"Country"}},
+		{"lower case country", invalid(func(cr *CreateRequest) { cr.Country = "gb" }), []string{"Country"}},
+		{"currency", invalid(func(cr *CreateRequest) { cr.BaseCurrency = "XYZ" }), []string{"BaseCurrency"}},
+		{"bic", invalid(func(cr *CreateRequest) { cr.Bic = "NWBKGB2" }), []string{"Bic"}},
+		{"five name lines", invalid(func(cr *CreateRequest) { cr.Name = []string{"A", "B", "C", "D", "E"} }), []string{"Name"}},
+		{"blank name line", invalid(func(cr *CreateRequest) { cr.Name = []string{"A", " "} }), []string{"Name[1]"}},
+		{"alternative names", invalid(func(cr *CreateRequest) { cr.AlternativeNames = []string{"A", "B", "C", "D"} }), []string{"AlternativeNames"}},
+	}
+
+	for _, tt := range cases {
+		err := tt.in.Validate()
+		if tt.want == nil {
+			if err != nil {
+				t.Errorf("CreateRequest_Validate(%v) got: %v, want: nil", tt.name, err)
+			}
+			continue
+		}
+
+		var vErr *ValidationError
+		if !errors.As(err, &vErr) || !errors.Is(err, ErrInvalidRequest) {
+			t.Errorf("CreateRequest_Validate(%v) got: %v, want: %v", tt.name, err, ErrInvalidRequest)
+			continue
+		}
+		var got []string
+		for _, f := range vErr.Fields {
+			got = append(got, f.Field)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("CreateRequest_Validate(%v) got: %v, want: %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestValidationError_Error(t *testing.T) {
+	err := &ValidationError{Fields: []FieldError{
+		{Field: "ID", Message: "must be a UUID 4"},
+		{Field: "Name", Message: "must have 1 to 4 lines"},
+	}}
+
+	want := "invalid request: ID: must be a UUID 4; Name: must have 1 to 4 lines"
+	if got := err.Error(); got != want {
+		t.Errorf("ValidationError_Error got: %v, want: %v", got, want)
+	}
+}
+
+func TestServiceCreate_Validation(t *testing.T) {
+	cr := _basicFilledCreateRequest
+	cr.Country = ""
+
+	cases := []struct {
+		name string
+		opts []serviceOption
+		want error
+	}{
+		{"enabled by default", nil, ErrInvalidRequest},
+		{"enabled", []serviceOption{WithValidation(true)}, ErrInvalidRequest},
+		{"disabled", []serviceOption{WithValidation(false)}, ErrBadRequest},
+	}
+
+	for _, tt := range cases {
+		repo := NewInMemoryRepository()
+		_, err := NewService(repo, tt.opts...).Create(cr)
+		if !errors.Is(err, tt.want) {
+			t.Errorf("ServiceCreate_Validation(%v) got: %v, want: %v", tt.name, err, tt.want)
+		}
+	}
+}